@@ -1,7 +1,14 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/guilhermebr/minesweeper/minesweeper"
@@ -14,15 +21,40 @@ import (
 type Services struct {
 	logger      *logrus.Logger
 	GameService types.GameService
+	Janitor     *minesweeper.Janitor
+	Timeouts    *minesweeper.Timeouts
 }
 
+// shutdownTimeout bounds the time given to requests in flight to finish once
+// the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// presetsEnv names the environment variable holding the difficulties added
+// by the operator, as a JSON object of presets by name.
+const presetsEnv = "MINESWEEPER_PRESETS"
+
 func Start(log *logrus.Logger) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+
 	db := memory.New()
+	gameService := &minesweeper.GameService{
+		Store:   memory.NewGameStore(db),
+		Presets: presets,
+	}
+	janitor := &minesweeper.Janitor{
+		Service: gameService,
+		TTL:     minesweeper.DefaultTTL,
+	}
+	timeouts := &minesweeper.Timeouts{Service: gameService}
+	gameService.Timeouts = timeouts
 	services := Services{
-		logger: log,
-		GameService: &minesweeper.GameService{
-			Store: memory.NewGameStore(db),
-		},
+		logger:      log,
+		GameService: gameService,
+		Janitor:     janitor,
+		Timeouts:    timeouts,
 	}
 
 	// API Routes
@@ -32,18 +64,71 @@ func Start(log *logrus.Logger) error {
 	n := negroni.Classic()
 	n.UseHandler(r)
 
+	janitor.Start()
+	defer janitor.Stop()
+	timeouts.Start()
+	defer timeouts.Stop()
+
 	//Run Server
+	server := &http.Server{Addr: ":3000", Handler: n}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
 	log.Infoln("Server running on port :3000")
-	http.ListenAndServe(":3000", n)
-	return nil
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		log.WithField("signal", sig).Infoln("Shutting down")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+// loadPresets reads and checks the presets of the operator from presetsEnv.
+func loadPresets() (map[string]minesweeper.Preset, error) {
+	data := os.Getenv(presetsEnv)
+	if data == "" {
+		return nil, nil
+	}
+
+	var presets map[string]minesweeper.Preset
+	if err := json.Unmarshal([]byte(data), &presets); err != nil {
+		return nil, fmt.Errorf("%s: %v", presetsEnv, err)
+	}
+	if err := minesweeper.ValidatePresets(presets); err != nil {
+		return nil, fmt.Errorf("%s: %v", presetsEnv, err)
+	}
+	return presets, nil
 }
 
 func Router(services *Services) *mux.Router {
 	// API Routes
 	r := mux.NewRouter()
 	r.HandleFunc("/healthcheck", services.healthcheck).Methods("GET")
+	r.HandleFunc("/metrics", services.metrics).Methods("GET")
 	r.HandleFunc("/game", services.createGame).Methods("POST")
+	r.HandleFunc("/games", services.listGames).Methods("GET")
+	r.HandleFunc("/game/{name}", services.getGame).Methods("GET")
+	r.HandleFunc("/game/{name}", services.deleteGame).Methods("DELETE")
 	r.HandleFunc("/game/{name}/start", services.startGame).Methods("POST")
 	r.HandleFunc("/game/{name}/click", services.clickCell).Methods("POST")
+	r.HandleFunc("/game/{name}/chord", services.chordCell).Methods("POST")
+	r.HandleFunc("/game/{name}/flag", services.flagCell).Methods("POST")
+	r.HandleFunc("/game/{name}/hint", services.hint).Methods("POST")
+	r.HandleFunc("/game/{name}/surrender", services.surrender).Methods("POST")
+	r.HandleFunc("/game/{name}/restart", services.restartGame).Methods("POST")
+	r.HandleFunc("/game/{name}/pause", services.pauseGame).Methods("POST")
+	r.HandleFunc("/game/{name}/resume", services.resumeGame).Methods("POST")
+	r.HandleFunc("/game/{name}/fork", services.forkGame).Methods("POST")
+	r.HandleFunc("/game/{name}/undo", services.undo).Methods("POST")
+	r.HandleFunc("/game/{name}/replay", services.replay).Methods("GET")
+	r.HandleFunc("/game/{name}/probabilities", services.probabilities).Methods("GET")
 	return r
 }
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"os"
+	"testing"
+
+	"github.com/guilhermebr/minesweeper/minesweeper"
+)
+
+func TestLoadPresets(t *testing.T) {
+	defer os.Unsetenv(presetsEnv)
+
+	os.Setenv(presetsEnv, `{"tiny": {"rows": 4, "cols": 4, "mines": 2}}`)
+	presets, err := loadPresets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (minesweeper.Preset{Rows: 4, Cols: 4, Mines: 2}); presets["tiny"] != want {
+		t.Errorf("unexpected preset. want=%+v, got=%+v", want, presets["tiny"])
+	}
+
+	for _, data := range []string{`{"tiny": 4}`, `{"crowded": {"rows": 4, "cols": 4, "mines": 12}}`} {
+		os.Setenv(presetsEnv, data)
+		if _, err := loadPresets(); err == nil {
+			t.Errorf("%s: invalid presets should fail to load", data)
+		}
+	}
+}
@@ -3,18 +3,79 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/guilhermebr/minesweeper/minesweeper"
+	"github.com/guilhermebr/minesweeper/storage/memory"
 )
 
 var (
 	ErrInternalServer = Error{StatusCode: http.StatusInternalServerError, Type: "server_error", Message: "Internal server error. The error has been logged and we are working on it"}
 	ErrInvalidJSON    = Error{StatusCode: http.StatusBadRequest, Type: "invalid_json", Message: "Invalid or malformed JSON"}
 	ErrAlreadyExists  = Error{StatusCode: http.StatusConflict, Type: "already_exists", Message: "Another resource has the same value as this field"}
+	ErrNotFound       = Error{StatusCode: http.StatusNotFound, Type: "not_found", Message: "Game not found"}
+	ErrInvalidState   = Error{StatusCode: http.StatusConflict, Type: "invalid_state", Message: "Action not allowed in the current game status"}
+	ErrInvalidRequest = Error{StatusCode: http.StatusUnprocessableEntity, Type: "invalid_request", Message: "One or more fields are invalid"}
+	ErrCellClicked    = Error{StatusCode: http.StatusConflict, Type: "cell_clicked", Message: "Cell already clicked"}
+	ErrCellFlagged    = Error{StatusCode: http.StatusConflict, Type: "cell_flagged", Message: "Cell is flagged"}
+	ErrInvalidChord   = Error{StatusCode: http.StatusUnprocessableEntity, Type: "invalid_chord", Message: "Cell cannot be chorded"}
+	ErrNoCellLeft     = Error{StatusCode: http.StatusConflict, Type: "no_cell_left", Message: "No cell left to open"}
+	ErrUndoDisabled   = Error{StatusCode: http.StatusForbidden, Type: "undo_disabled", Message: "Undo and restart are disabled on ranked games"}
+	ErrNoMoveToUndo   = Error{StatusCode: http.StatusConflict, Type: "no_move_to_undo", Message: "No move to undo"}
+	ErrConflict       = Error{StatusCode: http.StatusConflict, Type: "conflict", Message: "Game was changed by another request, try again"}
+	ErrPrecondition   = Error{StatusCode: http.StatusPreconditionFailed, Type: "precondition_failed", Message: "Game does not match the If-Match version"}
 )
 
+// errorFor maps an error returned by the game service to the API error sent
+// to clients. Unknown errors are internal server errors.
+func errorFor(err error) Error {
+	switch err {
+	case memory.ErrGameNotFound:
+		return ErrNotFound
+	case memory.ErrGameExists:
+		return ErrAlreadyExists
+	case memory.ErrConflict:
+		return ErrConflict
+	case memory.ErrInvalidCursor:
+		apiErr := ErrInvalidRequest
+		apiErr.Details = []minesweeper.FieldError{{Field: "cursor", Constraint: "must come from a previous listing"}}
+		return apiErr
+	case minesweeper.ErrVersionMismatch:
+		return ErrPrecondition
+	case minesweeper.ErrCellClicked:
+		return ErrCellClicked
+	case minesweeper.ErrCellFlagged:
+		return ErrCellFlagged
+	case minesweeper.ErrCellNotClicked, minesweeper.ErrNothingToChord, minesweeper.ErrFlagsMismatch:
+		return ErrInvalidChord.withMessage(err)
+	case minesweeper.ErrNoCellLeft:
+		return ErrNoCellLeft
+	case minesweeper.ErrRankedGame:
+		return ErrUndoDisabled
+	case minesweeper.ErrNoMoveToUndo:
+		return ErrNoMoveToUndo
+	}
+
+	switch e := err.(type) {
+	case *minesweeper.StateError:
+		return ErrInvalidState.withMessage(err)
+	case *minesweeper.ValidationError:
+		apiErr := ErrInvalidRequest
+		apiErr.Details = e.Fields
+		return apiErr
+	}
+	return ErrInternalServer
+}
+
 type Error struct {
-	StatusCode int    `json:"-"`
-	Type       string `json:"type"`
-	Message    string `json:"message,omitempty"`
+	StatusCode int                      `json:"-"`
+	Type       string                   `json:"type"`
+	Message    string                   `json:"message,omitempty"`
+	Details    []minesweeper.FieldError `json:"details,omitempty"`
+}
+
+func (e Error) withMessage(err error) Error {
+	e.Message = err.Error()
+	return e
 }
 
 func (e Error) Send(w http.ResponseWriter) error {
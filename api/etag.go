@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// setETag tags the response with the version of game, so clients can make
+// their next change conditional on it with If-Match.
+func setETag(w http.ResponseWriter, game *types.Game) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(game.Version, 10)))
+}
+
+// ifMatch returns the game version required by the If-Match header of r,
+// zero when there is no header or it matches any version. It returns false
+// when the header does not hold a single game tag.
+func ifMatch(r *http.Request) (int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil || !strings.HasPrefix(header, `"`) {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
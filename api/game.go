@@ -2,9 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/guilhermebr/minesweeper/minesweeper"
 	"github.com/guilhermebr/minesweeper/types"
 	"github.com/sirupsen/logrus"
 )
@@ -15,27 +20,176 @@ import (
 // responses:
 //   201: Game created
 //   400: Invalid json
+//   409: Game already exists
+//   422: Invalid fields
 //	 500: server error
 func (s *Services) createGame(w http.ResponseWriter, r *http.Request) {
-	var game types.Game
-
 	log := s.logger.WithFields(logrus.Fields{
 		"service": "game",
 		"method":  "create",
 	})
 
-	if err := json.NewDecoder(r.Body).Decode(&game); err != nil {
+	// Only the settings of the game are read, the rest of it is owned by the
+	// server.
+	var settings struct {
+		Name       string           `json:"name"`
+		Owner      string           `json:"owner"`
+		Difficulty string           `json:"difficulty"`
+		Rows       int              `json:"rows"`
+		Cols       int              `json:"cols"`
+		Mines      int              `json:"mines"`
+		Seed       int64            `json:"seed"`
+		FirstClick types.FirstClick `json:"first_click"`
+		NoGuess    bool             `json:"no_guess"`
+		Ranked     bool             `json:"ranked"`
+		TimeLimit  int64            `json:"time_limit_ms"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
 		log.Error(err)
 		ErrInvalidJSON.Send(w)
 		return
 	}
 
+	game := types.Game{
+		Name:       settings.Name,
+		Owner:      settings.Owner,
+		Difficulty: settings.Difficulty,
+		Rows:       settings.Rows,
+		Cols:       settings.Cols,
+		Mines:      settings.Mines,
+		Seed:       settings.Seed,
+		FirstClick: settings.FirstClick,
+		NoGuess:    settings.NoGuess,
+		Ranked:     settings.Ranked,
+		TimeLimit:  settings.TimeLimit,
+	}
+
 	if err := s.GameService.Create(&game); err != nil {
 		log.WithField("err", err).Error("cannot create game")
-		ErrInternalServer.Send(w)
+		errorFor(err).Send(w)
 		return
 	}
-	Success(game, http.StatusCreated).Send(w)
+	setETag(w, &game)
+	Success(minesweeper.View(&game), http.StatusCreated).Send(w)
+}
+
+// title: get game
+// path: /game/{name}
+// method: GET
+// responses:
+//   200: OK
+//   404: Game not found
+//   500: server error
+func (s *Services) getGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "get",
+	})
+
+	game, err := s.GameService.Get(name)
+	if err != nil {
+		log.WithField("err", err).Error("cannot get game")
+		errorFor(err).Send(w)
+		return
+	}
+
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusOK).Send(w)
+}
+
+// title: list games
+// path: /games
+// method: GET
+// responses:
+//   200: OK
+//   422: Invalid filter or cursor
+//   500: server error
+func (s *Services) listGames(w http.ResponseWriter, r *http.Request) {
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "list",
+	})
+
+	query, err := parseGameQuery(r.URL.Query())
+	if err != nil {
+		log.Error(err)
+		errorFor(err).Send(w)
+		return
+	}
+
+	page, err := s.GameService.List(query)
+	if err != nil {
+		log.WithField("err", err).Error("cannot list games")
+		errorFor(err).Send(w)
+		return
+	}
+
+	var result struct {
+		Games []types.GameSummary
+		Next  string `json:",omitempty"`
+	}
+
+	result.Games = make([]types.GameSummary, len(page.Games))
+	for i, game := range page.Games {
+		result.Games[i] = minesweeper.Summary(game)
+	}
+	result.Next = page.Next
+
+	Success(&result, http.StatusOK).Send(w)
+}
+
+// parseGameQuery reads the filters of a listing from the URL query values.
+func parseGameQuery(values url.Values) (types.GameQuery, error) {
+	query := types.GameQuery{
+		Status: types.Status(values.Get("status")),
+		Owner:  values.Get("owner"),
+		Cursor: values.Get("cursor"),
+	}
+	verr := &minesweeper.ValidationError{}
+
+	times := []struct {
+		field string
+		value *time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+	}
+	for _, t := range times {
+		if v := values.Get(t.field); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				verr.Fields = append(verr.Fields, minesweeper.FieldError{Field: t.field, Constraint: "must be an RFC 3339 time"})
+			}
+			*t.value = parsed
+		}
+	}
+
+	ints := []struct {
+		field string
+		value *int
+	}{
+		{"rows", &query.Rows},
+		{"cols", &query.Cols},
+		{"limit", &query.Limit},
+	}
+	for _, n := range ints {
+		if v := values.Get(n.field); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				verr.Fields = append(verr.Fields, minesweeper.FieldError{Field: n.field, Constraint: "must be an integer"})
+			}
+			*n.value = parsed
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return query, verr
+	}
+	return query, nil
 }
 
 // title: start game
@@ -43,6 +197,9 @@ func (s *Services) createGame(w http.ResponseWriter, r *http.Request) {
 // method: POST
 // responses:
 //   200: OK
+//   404: Game not found
+//   409: Invalid state or concurrent update
+//   412: If-Match does not match the game version
 //   500: server error
 func (s *Services) startGame(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -53,17 +210,21 @@ func (s *Services) startGame(w http.ResponseWriter, r *http.Request) {
 		"method":  "start",
 	})
 
-	game, err := s.GameService.Start(name)
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Start(name, version)
 	if err != nil {
 		log.WithField("err", err).Error("cannot start game")
-		ErrInternalServer.Send(w)
+		errorFor(err).Send(w)
 		return
 	}
 
-	game2 := *game
-	game2.Grid = nil
-
-	Success(game2, http.StatusOK).Send(w)
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusOK).Send(w)
 }
 
 // title: cell click
@@ -72,6 +233,10 @@ func (s *Services) startGame(w http.ResponseWriter, r *http.Request) {
 // responses:
 //   200: OK
 //   400: Invalid json
+//   404: Game not found
+//   409: Invalid state, cell clicked or flagged, or concurrent update
+//   412: If-Match does not match the game version
+//   422: Invalid cell
 //   500: server error
 func (s *Services) clickCell(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -82,6 +247,12 @@ func (s *Services) clickCell(w http.ResponseWriter, r *http.Request) {
 		"method":  "click",
 	})
 
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
 	var cellPos struct {
 		Row int `json:"row"`
 		Col int `json:"col"`
@@ -93,25 +264,525 @@ func (s *Services) clickCell(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	game, err := s.GameService.Click(name, cellPos.Row, cellPos.Col)
+	game, revealed, err := s.GameService.Click(name, cellPos.Row, cellPos.Col, version)
 	if err != nil {
 		log.WithField("err", err).Error("cannot click cell")
-		ErrInternalServer.Send(w)
+		errorFor(err).Send(w)
+		return
+	}
+	setETag(w, game)
+
+	var result struct {
+		Cell     types.CellView
+		Game     *types.GameView
+		Revealed []types.Position
+	}
+
+	result.Cell = minesweeper.CellView(game, cellPos.Row, cellPos.Col)
+	result.Game = minesweeper.View(game)
+	result.Revealed = revealed
+
+	Success(&result, http.StatusOK).Send(w)
+}
+
+// title: cell chord
+// path: /game/{name}/chord
+// method: POST
+// responses:
+//   200: OK
+//   400: Invalid json
+//   404: Game not found
+//   409: Invalid state or concurrent update
+//   412: If-Match does not match the game version
+//   422: Invalid cell or cell cannot be chorded
+//   500: server error
+func (s *Services) chordCell(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "chord",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	var cellPos struct {
+		Row int `json:"row"`
+		Col int `json:"col"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&cellPos); err != nil {
+		log.Error(err)
+		ErrInvalidJSON.Send(w)
+		return
+	}
+
+	game, revealed, err := s.GameService.Chord(name, cellPos.Row, cellPos.Col, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot chord cell")
+		errorFor(err).Send(w)
+		return
+	}
+	setETag(w, game)
+
+	var result struct {
+		Cell     types.CellView
+		Game     *types.GameView
+		Revealed []types.Position
+	}
+
+	result.Cell = minesweeper.CellView(game, cellPos.Row, cellPos.Col)
+	result.Game = minesweeper.View(game)
+	result.Revealed = revealed
+
+	Success(&result, http.StatusOK).Send(w)
+}
+
+// title: cell flag
+// path: /game/{name}/flag
+// method: POST
+// responses:
+//   200: OK
+//   400: Invalid json
+//   404: Game not found
+//   409: Invalid state, cell clicked or concurrent update
+//   412: If-Match does not match the game version
+//   422: Invalid cell
+//   500: server error
+func (s *Services) flagCell(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "flag",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	var cellPos struct {
+		Row int `json:"row"`
+		Col int `json:"col"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&cellPos); err != nil {
+		log.Error(err)
+		ErrInvalidJSON.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Flag(name, cellPos.Row, cellPos.Col, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot flag cell")
+		errorFor(err).Send(w)
+		return
+	}
+	setETag(w, game)
+
+	var result struct {
+		Cell types.CellView
+		Game *types.GameView
+	}
+
+	result.Cell = minesweeper.CellView(game, cellPos.Row, cellPos.Col)
+	result.Game = minesweeper.View(game)
+
+	Success(&result, http.StatusOK).Send(w)
+}
+
+// title: hint
+// path: /game/{name}/hint
+// method: POST
+// responses:
+//   200: OK
+//   404: Game not found
+//   409: Invalid state or concurrent update
+//   412: If-Match does not match the game version
+//   500: server error
+func (s *Services) hint(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "hint",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	game, hint, err := s.GameService.Hint(name, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot get hint")
+		errorFor(err).Send(w)
+		return
+	}
+	setETag(w, game)
+
+	var result struct {
+		Hint types.Hint
+		Game *types.GameView
+	}
+
+	result.Hint = *hint
+	result.Game = minesweeper.View(game)
+
+	Success(&result, http.StatusOK).Send(w)
+}
+
+// title: surrender
+// path: /game/{name}/surrender
+// method: POST
+// responses:
+//   200: OK
+//   404: Game not found
+//   409: Invalid state or concurrent update
+//   412: If-Match does not match the game version
+//   500: server error
+func (s *Services) surrender(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "surrender",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Surrender(name, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot surrender game")
+		errorFor(err).Send(w)
+		return
+	}
+
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusOK).Send(w)
+}
+
+// title: restart game
+// path: /game/{name}/restart
+// method: POST
+// responses:
+//   200: OK
+//   403: Restart disabled on ranked games
+//   404: Game not found
+//   409: Invalid state or concurrent update
+//   412: If-Match does not match the game version
+//   500: server error
+func (s *Services) restartGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "restart",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Restart(name, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot restart game")
+		errorFor(err).Send(w)
+		return
+	}
+
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusOK).Send(w)
+}
+
+// title: pause game
+// path: /game/{name}/pause
+// method: POST
+// responses:
+//   200: OK
+//   404: Game not found
+//   409: Invalid state or concurrent update
+//   412: If-Match does not match the game version
+//   500: server error
+func (s *Services) pauseGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "pause",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Pause(name, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot pause game")
+		errorFor(err).Send(w)
 		return
 	}
-	cell := game.Grid[cellPos.Row][cellPos.Col]
 
-	if game.Status != "over" && game.Status != "won" {
-		game.Grid = nil
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusOK).Send(w)
+}
+
+// title: resume game
+// path: /game/{name}/resume
+// method: POST
+// responses:
+//   200: OK
+//   404: Game not found
+//   409: Invalid state or concurrent update
+//   412: If-Match does not match the game version
+//   500: server error
+func (s *Services) resumeGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "resume",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Resume(name, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot resume game")
+		errorFor(err).Send(w)
+		return
+	}
+
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusOK).Send(w)
+}
+
+// title: undo moves
+// path: /game/{name}/undo
+// method: POST
+// responses:
+//   200: OK
+//   400: Invalid json
+//   403: Undo disabled on ranked games
+//   404: Game not found
+//   409: Invalid state, no move to undo or concurrent update
+//   412: If-Match does not match the game version
+//   422: Invalid number of moves
+//   500: server error
+func (s *Services) undo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "undo",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	// The body is optional, a single move is taken back by default.
+	undo := struct {
+		Moves int `json:"moves"`
+	}{Moves: 1}
+
+	if err := json.NewDecoder(r.Body).Decode(&undo); err != nil && err != io.EOF {
+		log.Error(err)
+		ErrInvalidJSON.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Undo(name, undo.Moves, version)
+	if err != nil {
+		log.WithField("err", err).Error("cannot undo moves")
+		errorFor(err).Send(w)
+		return
+	}
+
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusOK).Send(w)
+}
+
+// title: replay
+// path: /game/{name}/replay
+// method: GET
+// responses:
+//   200: OK
+//   404: Game not found
+//   409: Invalid state
+//   422: Invalid step
+//   500: server error
+func (s *Services) replay(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "replay",
+	})
+
+	replay, err := s.GameService.Replay(name)
+	if err != nil {
+		log.WithField("err", err).Error("cannot get replay")
+		errorFor(err).Send(w)
+		return
+	}
+
+	step := r.URL.Query().Get("step")
+	if step == "" {
+		Success(replay, http.StatusOK).Send(w)
+		return
+	}
+
+	k, err := strconv.Atoi(step)
+	if err != nil {
+		log.Error(err)
+		errorFor(&minesweeper.ValidationError{Fields: []minesweeper.FieldError{{Field: "step", Constraint: "must be an integer"}}}).Send(w)
+		return
+	}
+
+	game, err := minesweeper.ReplayTo(replay, k)
+	if err != nil {
+		log.WithField("err", err).Error("cannot replay game")
+		errorFor(err).Send(w)
+		return
 	}
 
 	var result struct {
-		Cell types.Cell
-		Game types.Game
+		Step int             `json:"step"`
+		Game *types.GameView `json:"game"`
 	}
 
-	result.Cell = cell
-	result.Game = *game
+	result.Step = k
+	result.Game = minesweeper.View(game)
 
 	Success(&result, http.StatusOK).Send(w)
 }
+
+// title: fork game
+// path: /game/{name}/fork
+// method: POST
+// responses:
+//   201: Game created
+//   400: Invalid json
+//   404: Game not found
+//   409: Game already exists or ranked game not finished
+//   422: Invalid name
+//   500: server error
+func (s *Services) forkGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "fork",
+	})
+
+	var fork struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&fork); err != nil {
+		log.Error(err)
+		ErrInvalidJSON.Send(w)
+		return
+	}
+
+	game, err := s.GameService.Fork(name, fork.Name)
+	if err != nil {
+		log.WithField("err", err).Error("cannot fork game")
+		errorFor(err).Send(w)
+		return
+	}
+
+	setETag(w, game)
+	Success(minesweeper.View(game), http.StatusCreated).Send(w)
+}
+
+// title: delete game
+// path: /game/{name}
+// method: DELETE
+// responses:
+//   204: Game deleted
+//   404: Game not found
+//   412: If-Match does not match the game version
+//   500: server error
+func (s *Services) deleteGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "delete",
+	})
+
+	version, ok := ifMatch(r)
+	if !ok {
+		ErrPrecondition.Send(w)
+		return
+	}
+
+	if err := s.GameService.Delete(name, version); err != nil {
+		log.WithField("err", err).Error("cannot delete game")
+		errorFor(err).Send(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// title: mine probabilities
+// path: /game/{name}/probabilities
+// method: GET
+// responses:
+//   200: OK
+//   404: Game not found
+//   409: Invalid state
+//   500: server error
+func (s *Services) probabilities(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	log := s.logger.WithFields(logrus.Fields{
+		"service": "game",
+		"method":  "probabilities",
+	})
+
+	probs, err := s.GameService.Probabilities(name)
+	if err != nil {
+		log.WithField("err", err).Error("cannot compute probabilities")
+		errorFor(err).Send(w)
+		return
+	}
+
+	Success(probs, http.StatusOK).Send(w)
+}
@@ -4,10 +4,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/guilhermebr/minesweeper/minesweeper"
 	"github.com/guilhermebr/minesweeper/mocks"
+	"github.com/guilhermebr/minesweeper/storage/memory"
 	"github.com/guilhermebr/minesweeper/types"
 	"github.com/sirupsen/logrus"
 )
@@ -52,7 +56,7 @@ func TestCreateGame_Success(t *testing.T) {
 	}
 
 	// Check the response body.
-	expected := `{"success":true,"status":201,"result":{"name":"teste","rows":10,"cols":12,"mines":30,"status":"new"}}`
+	expected := `{"success":true,"status":201,"result":{"name":"teste","rows":10,"cols":12,"mines":30,"remaining_mines":0,"status":"new","elapsed_ms":0}}`
 	if !strings.Contains(rr.Body.String(), expected) {
 		t.Errorf("handler returned unexpected body: want %v, got %v",
 			expected, rr.Body.String())
@@ -128,12 +132,293 @@ func TestCreateGame_ServerError(t *testing.T) {
 	}
 }
 
+func TestCreateGame_IgnoresGrid(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger:      log,
+		GameService: &minesweeper.GameService{Store: memory.NewGameStore(memory.New())},
+	}
+
+	data := `{"name":"teste","rows":3,"cols":3,"mines":2,"grid":[[{"mine":true,"clicked":true}]]}`
+	req, err := http.NewRequest("POST", "/game", strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: want %v, got %v", http.StatusCreated, status)
+	}
+
+	req, err = http.NewRequest("GET", "/game/teste", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: want %v, got %v", http.StatusOK, status)
+	}
+	if strings.Contains(rr.Body.String(), `"grid"`) {
+		t.Errorf("the grid of the request should be ignored, got %v", rr.Body.String())
+	}
+}
+
+func TestCreateGame_Settings(t *testing.T) {
+	log := logrus.StandardLogger()
+	want := types.Game{
+		Name:       "teste",
+		Owner:      "alice",
+		Difficulty: "custom",
+		Rows:       9,
+		Cols:       8,
+		Mines:      7,
+		Seed:       6,
+		FirstClick: types.FirstClickOpening,
+		NoGuess:    true,
+		Ranked:     true,
+		TimeLimit:  5000,
+	}
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnCreate: func(game *types.Game) error {
+				if !reflect.DeepEqual(*game, want) {
+					t.Errorf("only the settings should be read. want=%+v, got=%+v", want, *game)
+				}
+				return nil
+			},
+		},
+	}
+
+	// Everything past the settings is owned by the server.
+	data := `{"name":"teste","owner":"alice","difficulty":"custom","rows":9,"cols":8,"mines":7,"seed":6,
+		"first_click":"opening","no_guess":true,"ranked":true,"time_limit_ms":5000,
+		"status":"won","parent":"other","remaining_mines":0,"guess_free":true,"hints":-3,"undos":-1,
+		"version":9,"moves":[{"kind":"reveal"}],"started_at":"2000-01-01T00:00:00Z","paused_for":5}`
+	req, err := http.NewRequest("POST", "/game", strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: want %v, got %v", http.StatusCreated, status)
+	}
+}
+
+func TestGetGame_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnGet: func(name string) (*types.Game, error) {
+				if name != "teste" {
+					t.Fatalf("unexpected name. want=teste, got=%s", name)
+				}
+
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0, Mark: types.MarkFlag},
+					},
+				}
+
+				return &types.Game{
+					Name:    "teste",
+					Status:  "started",
+					Rows:    1,
+					Cols:    2,
+					Mines:   1,
+					Seed:    42,
+					Version: 5,
+					Grid:    grid,
+				}, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/game/teste", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+	if etag := rr.Header().Get("ETag"); etag != `"5"` {
+		t.Errorf("handler returned unexpected ETag: want %v, got %v", `"5"`, etag)
+	}
+
+	// Check the response body: the seed and the hidden mine stay secret.
+	expected := `{"success":true,"status":200,"result":{"name":"teste","rows":1,"cols":2,"mines":1,"remaining_mines":0,"status":"started","elapsed_ms":0,"grid":[[{"clicked":true,"mine":false,"value":1},{"clicked":false,"mark":"flag"}]],"version":5}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestGetGame_NotFound(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnGet: func(name string) (*types.Game, error) {
+				return nil, memory.ErrGameNotFound
+			},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/game/teste", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusNotFound, status)
+	}
+}
+
+func TestListGames_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnList: func(query types.GameQuery) (*types.GamePage, error) {
+				if query.Status != types.StatusStarted || query.Owner != "bob" || query.Rows != 9 || query.Limit != 1 || query.Cursor != "abc" {
+					t.Fatalf("unexpected query: %+v", query)
+				}
+				if !query.CreatedAfter.Equal(created) {
+					t.Fatalf("unexpected created_after. want=%v, got=%v", created, query.CreatedAfter)
+				}
+
+				game := &types.Game{
+					Name:      "teste",
+					Owner:     "bob",
+					CreatedAt: created,
+					UpdatedAt: created.Add(time.Hour),
+					Rows:      9,
+					Cols:      9,
+					Mines:     10,
+					Status:    types.StatusStarted,
+					Version:   3,
+					Grid:      []types.CellGrid{{{Mine: true}}},
+				}
+				return &types.GamePage{Games: []*types.Game{game}, Next: "def"}, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/games?status=started&owner=bob&rows=9&limit=1&cursor=abc&created_after=2020-01-02T03:04:05Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body.
+	expected := `{"success":true,"status":200,"result":{"Games":[{"name":"teste","owner":"bob","created_at":"2020-01-02T03:04:05Z","updated_at":"2020-01-02T04:04:05Z","rows":9,"cols":9,"mines":10,"status":"started","elapsed_ms":0,"version":3}],"Next":"def"}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestListGames_InvalidQuery(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnList: func(query types.GameQuery) (*types.GamePage, error) {
+				t.Fatal("games should not be listed")
+				return nil, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/games?rows=nine&created_before=yesterday", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusUnprocessableEntity, status)
+	}
+
+	// Check the response body.
+	expected := `"details":[{"field":"created_before","constraint":"must be an RFC 3339 time"},{"field":"rows","constraint":"must be an integer"}]`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		Janitor: &minesweeper.Janitor{
+			Service: &minesweeper.GameService{
+				Store: &mocks.MockGameStore{
+					OnList: func(query types.GameQuery) (*types.GamePage, error) {
+						return &types.GamePage{}, nil
+					},
+				},
+			},
+			TTL: minesweeper.DefaultTTL,
+		},
+	}
+	services.Janitor.Sweep()
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body.
+	expected := `{"success":true,"status":200,"result":{"Janitor":{"sweeps":1,"reaped":{},"errors":0},"Timeouts":{"pending":0,"expired":0,"errors":0}}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
 func TestStartGame_Success(t *testing.T) {
 	log := logrus.StandardLogger()
 	services := &Services{
 		logger: log,
 		GameService: &mocks.MockGameService{
-			OnStart: func(name string) (*types.Game, error) {
+			OnStart: func(name string, version int64) (*types.Game, error) {
 				if name != "teste" {
 					t.Fatalf("unexpected name. want=teste, got=%s", name)
 				}
@@ -175,7 +460,7 @@ func TestStartGame_Success(t *testing.T) {
 	}
 
 	// Check the response body.
-	expected := `{"success":true,"status":200,"result":{"name":"teste","rows":2,"cols":2,"mines":4,"status":"started"}}`
+	expected := `{"success":true,"status":200,"result":{"name":"teste","rows":2,"cols":2,"mines":4,"remaining_mines":0,"status":"started","elapsed_ms":0,"grid":[[{"clicked":false},{"clicked":false}],[{"clicked":false},{"clicked":false}]]}}`
 	if !strings.Contains(rr.Body.String(), expected) {
 		t.Errorf("handler returned unexpected body: want %v, got %v",
 			expected, rr.Body.String())
@@ -187,7 +472,7 @@ func TestStartGame_ServerError(t *testing.T) {
 	services := &Services{
 		logger: log,
 		GameService: &mocks.MockGameService{
-			OnStart: func(name string) (*types.Game, error) {
+			OnStart: func(name string, version int64) (*types.Game, error) {
 				return nil, errors.New("error")
 			},
 		},
@@ -219,11 +504,11 @@ func TestClickCell_Success(t *testing.T) {
 	services := &Services{
 		logger: log,
 		GameService: &mocks.MockGameService{
-			OnClick: func(name string, i, j int) (*types.Game, error) {
+			OnClick: func(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
 				grid := []types.CellGrid{
 					types.CellGrid{
 						types.Cell{Mine: false, Clicked: false, Value: 1},
-						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: true, Value: 1},
 					},
 					types.CellGrid{
 						types.Cell{Mine: false, Clicked: false, Value: 1},
@@ -238,7 +523,7 @@ func TestClickCell_Success(t *testing.T) {
 					Cols:   2,
 					Mines:  1,
 					Grid:   grid,
-				}, nil
+				}, []types.Position{{Row: i, Col: j}}, nil
 			},
 		},
 	}
@@ -259,7 +544,7 @@ func TestClickCell_Success(t *testing.T) {
 	}
 
 	// Check the response body.
-	expected := `{"success":true,"status":200,"result":{"Cell":{"mine":false,"clicked":false,"value":1},"Game":{"name":"teste","rows":2,"cols":2,"mines":1,"status":"started"}}}`
+	expected := `{"success":true,"status":200,"result":{"Cell":{"clicked":true,"mine":false,"value":1},"Game":{"name":"teste","rows":2,"cols":2,"mines":1,"remaining_mines":0,"status":"started","elapsed_ms":0,"grid":[[{"clicked":false},{"clicked":true,"mine":false,"value":1}],[{"clicked":false},{"clicked":false}]]},"Revealed":[{"row":0,"col":1}]}}`
 	if !strings.Contains(rr.Body.String(), expected) {
 		t.Errorf("handler returned unexpected body: want %v, got %v",
 			expected, rr.Body.String())
@@ -271,7 +556,7 @@ func TestClickCell_GameOver(t *testing.T) {
 	services := &Services{
 		logger: log,
 		GameService: &mocks.MockGameService{
-			OnClick: func(name string, i, j int) (*types.Game, error) {
+			OnClick: func(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
 				grid := []types.CellGrid{
 					types.CellGrid{
 						types.Cell{Mine: false, Clicked: false, Value: 1},
@@ -279,7 +564,7 @@ func TestClickCell_GameOver(t *testing.T) {
 					},
 					types.CellGrid{
 						types.Cell{Mine: false, Clicked: false, Value: 1},
-						types.Cell{Mine: true, Clicked: false, Value: 0},
+						types.Cell{Mine: true, Clicked: true, Value: 0},
 					},
 				}
 
@@ -290,7 +575,7 @@ func TestClickCell_GameOver(t *testing.T) {
 					Cols:   2,
 					Mines:  1,
 					Grid:   grid,
-				}, nil
+				}, []types.Position{{Row: i, Col: j}}, nil
 			},
 		},
 	}
@@ -311,7 +596,7 @@ func TestClickCell_GameOver(t *testing.T) {
 	}
 
 	// Check the response body.
-	expected := `{"success":true,"status":200,"result":{"Cell":{"mine":true,"clicked":false,"value":0},"Game":{"name":"teste","rows":2,"cols":2,"mines":1,"status":"over","grid":[[{"mine":false,"clicked":false,"value":1},{"mine":false,"clicked":false,"value":1}],[{"mine":false,"clicked":false,"value":1},{"mine":true,"clicked":false,"value":0}]]}}}`
+	expected := `{"success":true,"status":200,"result":{"Cell":{"clicked":true,"mine":true,"value":0},"Game":{"name":"teste","rows":2,"cols":2,"mines":1,"remaining_mines":0,"status":"over","elapsed_ms":0,"grid":[[{"clicked":false,"mine":false,"value":1},{"clicked":false,"mine":false,"value":1}],[{"clicked":false,"mine":false,"value":1},{"clicked":true,"mine":true,"value":0}]]},"Revealed":[{"row":1,"col":1}]}}`
 	if !strings.Contains(rr.Body.String(), expected) {
 		t.Errorf("handler returned unexpected body: want %v, got %v",
 			expected, rr.Body.String())
@@ -323,9 +608,9 @@ func TestClickCell_InvalidJson(t *testing.T) {
 	services := &Services{
 		logger: log,
 		GameService: &mocks.MockGameService{
-			OnClick: func(name string, i, j int) (*types.Game, error) {
+			OnClick: func(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
 				t.Fatal("game click should not be called")
-				return nil, nil
+				return nil, nil, nil
 			},
 		},
 	}
@@ -358,8 +643,8 @@ func TestClickCell_ServerError(t *testing.T) {
 	services := &Services{
 		logger: log,
 		GameService: &mocks.MockGameService{
-			OnClick: func(name string, i, j int) (*types.Game, error) {
-				return nil, errors.New("error")
+			OnClick: func(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+				return nil, nil, errors.New("error")
 			},
 		},
 	}
@@ -386,3 +671,639 @@ func TestClickCell_ServerError(t *testing.T) {
 			expected, rr.Body.String())
 	}
 }
+
+func TestFlagCell_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnFlag: func(name string, i, j int, version int64) (*types.Game, error) {
+				if i != 1 || j != 0 {
+					t.Fatalf("unexpected cell. want=(1,0), got=(%d,%d)", i, j)
+				}
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1, Mark: types.MarkFlag},
+						types.Cell{Mine: true, Clicked: false, Value: 0},
+					},
+				}
+
+				return &types.Game{
+					Name:           name,
+					Status:         "started",
+					Rows:           2,
+					Cols:           2,
+					Mines:          1,
+					RemainingMines: 0,
+					Grid:           grid,
+				}, nil
+			},
+		},
+	}
+
+	data := `{"row": 1, "col":0}`
+	b := strings.NewReader(data)
+	req, err := http.NewRequest("POST", "/game/teste/flag", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body.
+	expected := `{"success":true,"status":200,"result":{"Cell":{"clicked":false,"mark":"flag"},"Game":{"name":"teste","rows":2,"cols":2,"mines":1,"remaining_mines":0,"status":"started","elapsed_ms":0,"grid":[[{"clicked":false},{"clicked":false}],[{"clicked":false,"mark":"flag"},{"clicked":false}]]}}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestChordCell_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnChord: func(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0, Mark: types.MarkFlag},
+					},
+				}
+
+				return &types.Game{
+					Name:   name,
+					Status: "won",
+					Rows:   2,
+					Cols:   2,
+					Mines:  1,
+					Grid:   grid,
+				}, []types.Position{{Row: 0, Col: 1}, {Row: 1, Col: 0}}, nil
+			},
+		},
+	}
+
+	data := `{"row": 0, "col":0}`
+	b := strings.NewReader(data)
+	req, err := http.NewRequest("POST", "/game/teste/chord", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body.
+	expected := `"Revealed":[{"row":0,"col":1},{"row":1,"col":0}]`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestHint_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnHint: func(name string, version int64) (*types.Game, *types.Hint, error) {
+				return &types.Game{
+						Name:   name,
+						Status: "started",
+						Rows:   2,
+						Cols:   2,
+						Mines:  1,
+						Hints:  1,
+					}, &types.Hint{
+						Position: types.Position{Row: 1, Col: 1},
+						Safe:     true,
+						Reason:   "the 1 at (0,0) is already satisfied",
+					}, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("POST", "/game/teste/hint", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body.
+	expected := `{"success":true,"status":200,"result":{"Hint":{"row":1,"col":1,"safe":true,"risk":0,"reason":"the 1 at (0,0) is already satisfied"},"Game":{"name":"teste","rows":2,"cols":2,"mines":1,"remaining_mines":0,"status":"started","elapsed_ms":0,"hints":1}}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestProbabilities_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnProbabilities: func(name string) (*types.Probabilities, error) {
+				return &types.Probabilities{
+					Cells: []types.CellProbability{
+						{Position: types.Position{Row: 0, Col: 1}, Mine: 0.5},
+						{Position: types.Position{Row: 1, Col: 1}, Mine: 0.5},
+					},
+					Exact: true,
+				}, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/game/teste/probabilities", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body.
+	expected := `{"success":true,"status":200,"result":{"cells":[{"row":0,"col":1,"mine":0.5},{"row":1,"col":1,"mine":0.5}],"exact":true,"accuracy":0}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestClickCell_IfMatch(t *testing.T) {
+	log := logrus.StandardLogger()
+
+	tests := []struct {
+		ifMatch string
+		status  int
+		version int64
+	}{
+		{"", http.StatusOK, 0},
+		{"*", http.StatusOK, 0},
+		{`"3"`, http.StatusOK, 3},
+		{`W/"3"`, http.StatusPreconditionFailed, 0},
+		{"3", http.StatusPreconditionFailed, 0},
+		{`"abc"`, http.StatusPreconditionFailed, 0},
+	}
+
+	for _, tt := range tests {
+		called := false
+		services := &Services{
+			logger: log,
+			GameService: &mocks.MockGameService{
+				OnClick: func(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+					called = true
+					if version != tt.version {
+						t.Errorf("%q: unexpected version. want=%d, got=%d", tt.ifMatch, tt.version, version)
+					}
+					game := &types.Game{
+						Name:    name,
+						Rows:    1,
+						Cols:    2,
+						Status:  types.StatusStarted,
+						Version: 4,
+						Grid:    []types.CellGrid{make(types.CellGrid, 2)},
+					}
+					return game, nil, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest("POST", "/game/teste/click", strings.NewReader(`{"row": 0, "col": 0}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		Router(services).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != tt.status {
+			t.Errorf("%q: handler returned wrong status code: want %v, got %v", tt.ifMatch, tt.status, status)
+		}
+		if called != (tt.status == http.StatusOK) {
+			t.Errorf("%q: unexpected call to the game service: %v", tt.ifMatch, called)
+		}
+		if tt.status == http.StatusOK && rr.Header().Get("ETag") != `"4"` {
+			t.Errorf("%q: unexpected ETag. want=%q, got=%q", tt.ifMatch, `"4"`, rr.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestSurrender_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnSurrender: func(name string, version int64) (*types.Game, error) {
+				return &types.Game{
+					Name:    name,
+					Status:  types.StatusSurrendered,
+					Rows:    1,
+					Cols:    2,
+					Mines:   1,
+					Version: 4,
+					Grid:    []types.CellGrid{{{Clicked: true, Value: 1}, {Mine: true}}},
+				}, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("POST", "/game/teste/surrender", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body: the solution is revealed.
+	expected := `{"success":true,"status":200,"result":{"name":"teste","rows":1,"cols":2,"mines":1,"remaining_mines":0,"status":"surrendered","elapsed_ms":0,"grid":[[{"clicked":true,"mine":false,"value":1},{"clicked":false,"mine":true,"value":0}]],"version":4}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestRestartGame_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnRestart: func(name string, version int64) (*types.Game, error) {
+				if version != 7 {
+					t.Fatalf("unexpected version. want=7, got=%d", version)
+				}
+				return &types.Game{
+					Name:           name,
+					Status:         types.StatusStarted,
+					Rows:           1,
+					Cols:           2,
+					Mines:          1,
+					RemainingMines: 1,
+					Version:        8,
+					Grid:           []types.CellGrid{{{Value: 1}, {Mine: true}}},
+				}, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("POST", "/game/teste/restart", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("If-Match", `"7"`)
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusOK, status)
+	}
+
+	// Check the response body.
+	expected := `{"success":true,"status":200,"result":{"name":"teste","rows":1,"cols":2,"mines":1,"remaining_mines":1,"status":"started","elapsed_ms":0,"grid":[[{"clicked":false},{"clicked":false}]],"version":8}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	log := logrus.StandardLogger()
+	started := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	game := func(name string, status types.Status) *types.Game {
+		return &types.Game{
+			Name:      name,
+			Status:    status,
+			Rows:      1,
+			Cols:      2,
+			Mines:     1,
+			Version:   3,
+			Grid:      []types.CellGrid{{{Value: 1, Clicked: true}, {Mine: true}}},
+			StartedAt: &started,
+			Elapsed:   1500 * time.Millisecond,
+		}
+	}
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnPause: func(name string, version int64) (*types.Game, error) {
+				return game(name, types.StatusPaused), nil
+			},
+			OnResume: func(name string, version int64) (*types.Game, error) {
+				return game(name, types.StatusStarted), nil
+			},
+		},
+	}
+
+	tests := []struct {
+		action   string
+		expected string
+	}{
+		{"pause", `"result":{"name":"teste","rows":1,"cols":2,"mines":1,"remaining_mines":0,"status":"paused","started_at":"2020-01-02T03:04:05Z","elapsed_ms":1500,"version":3}`},
+		{"resume", `"result":{"name":"teste","rows":1,"cols":2,"mines":1,"remaining_mines":0,"status":"started","started_at":"2020-01-02T03:04:05Z","elapsed_ms":1500,"grid":[[{"clicked":true,"mine":false,"value":1},{"clicked":false}]],"version":3}`},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("POST", "/game/teste/"+tt.action, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Router(services).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("%s: handler returned wrong status code: want %v, got %v", tt.action, http.StatusOK, status)
+		}
+		if !strings.Contains(rr.Body.String(), tt.expected) {
+			t.Errorf("%s: handler returned unexpected body: want %v, got %v", tt.action, tt.expected, rr.Body.String())
+		}
+	}
+}
+
+func TestForkGame_Success(t *testing.T) {
+	log := logrus.StandardLogger()
+	services := &Services{
+		logger: log,
+		GameService: &mocks.MockGameService{
+			OnFork: func(name, newName string) (*types.Game, error) {
+				if name != "teste" || newName != "copy" {
+					t.Fatalf("unexpected fork. want=teste to copy, got=%s to %s", name, newName)
+				}
+				return &types.Game{
+					Name:    newName,
+					Parent:  name,
+					Status:  types.StatusStarted,
+					Rows:    1,
+					Cols:    2,
+					Mines:   1,
+					Version: 1,
+					Grid:    []types.CellGrid{{{Clicked: true, Value: 1}, {Mine: true}}},
+				}, nil
+			},
+		},
+	}
+
+	req, err := http.NewRequest("POST", "/game/teste/fork", strings.NewReader(`{"name":"copy"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	Router(services).ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: want %v, got %v",
+			http.StatusCreated, status)
+	}
+
+	// Check the response body.
+	expected := `{"success":true,"status":201,"result":{"name":"copy","parent":"teste","rows":1,"cols":2,"mines":1,"remaining_mines":0,"status":"started","elapsed_ms":0,"grid":[[{"clicked":true,"mine":false,"value":1},{"clicked":false}]],"version":1}}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: want %v, got %v",
+			expected, rr.Body.String())
+	}
+}
+
+func TestUndo(t *testing.T) {
+	log := logrus.StandardLogger()
+
+	tests := []struct {
+		data   string
+		moves  int
+		err    error
+		status int
+	}{
+		{"", 1, nil, http.StatusOK},
+		{`{"moves": 3}`, 3, nil, http.StatusOK},
+		{`{"moves": 1}`, 1, minesweeper.ErrRankedGame, http.StatusForbidden},
+		{`{"moves": 1}`, 1, minesweeper.ErrNoMoveToUndo, http.StatusConflict},
+		{`{moves}`, 0, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		services := &Services{
+			logger: log,
+			GameService: &mocks.MockGameService{
+				OnUndo: func(name string, moves int, version int64) (*types.Game, error) {
+					if moves != tt.moves {
+						t.Errorf("%q: unexpected moves. want=%d, got=%d", tt.data, tt.moves, moves)
+					}
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &types.Game{Name: name, Status: types.StatusStarted, Undos: 1}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest("POST", "/game/teste/undo", strings.NewReader(tt.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Router(services).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != tt.status {
+			t.Errorf("%q: handler returned wrong status code: want %v, got %v", tt.data, tt.status, status)
+		}
+		if tt.status == http.StatusOK && !strings.Contains(rr.Body.String(), `"undos":1`) {
+			t.Errorf("%q: handler returned unexpected body: %v", tt.data, rr.Body.String())
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	log := logrus.StandardLogger()
+
+	tests := []struct {
+		query  string
+		err    error
+		status int
+		body   string
+	}{
+		{"", nil, http.StatusOK, `"mine_positions":[{"row":0,"col":0}]`},
+		{"?step=1", nil, http.StatusOK, `"step":1,"game":{"name":"teste","rows":2,"cols":2,"mines":1,"remaining_mines":1,"status":"started","started_at":"2020-01-01T00:00:00Z","elapsed_ms":0,"grid":[[{"clicked":false},{"clicked":false}],[{"clicked":false},{"clicked":true,"mine":false,"value":1}]]}`},
+		{"?step=2", nil, http.StatusUnprocessableEntity, `"field":"step"`},
+		{"?step=last", nil, http.StatusUnprocessableEntity, `"field":"step"`},
+		{"", memory.ErrGameNotFound, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		services := &Services{
+			logger: log,
+			GameService: &mocks.MockGameService{
+				OnReplay: func(name string) (*types.Replay, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &types.Replay{
+						Name:          name,
+						Rows:          2,
+						Cols:          2,
+						Mines:         1,
+						MinePositions: []types.Position{{Row: 0, Col: 0}},
+						Moves:         []types.Move{{Kind: types.MoveReveal, Position: types.Position{Row: 1, Col: 1}, At: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+						Status:        types.StatusSurrendered,
+					}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest("GET", "/game/teste/replay"+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		Router(services).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != tt.status {
+			t.Errorf("%q: handler returned wrong status code: want %v, got %v", tt.query, tt.status, status)
+		}
+		if !strings.Contains(rr.Body.String(), tt.body) {
+			t.Errorf("%q: handler returned unexpected body: %v", tt.query, rr.Body.String())
+		}
+	}
+}
+
+func TestDeleteGame(t *testing.T) {
+	log := logrus.StandardLogger()
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{nil, http.StatusNoContent},
+		{memory.ErrGameNotFound, http.StatusNotFound},
+		{minesweeper.ErrVersionMismatch, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		services := &Services{
+			logger: log,
+			GameService: &mocks.MockGameService{
+				OnDelete: func(name string, version int64) error {
+					if name != "teste" || version != 2 {
+						t.Fatalf("unexpected delete. want=teste at 2, got=%s at %d", name, version)
+					}
+					return tt.err
+				},
+			},
+		}
+
+		req, err := http.NewRequest("DELETE", "/game/teste", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("If-Match", `"2"`)
+		rr := httptest.NewRecorder()
+		Router(services).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != tt.status {
+			t.Errorf("%v: handler returned wrong status code: want %v, got %v", tt.err, tt.status, status)
+		}
+	}
+}
+
+func TestGameErrors(t *testing.T) {
+	log := logrus.StandardLogger()
+
+	tests := []struct {
+		err      error
+		status   int
+		expected string
+	}{
+		{memory.ErrGameNotFound, http.StatusNotFound, `{"type":"not_found","message":"Game not found"}`},
+		{memory.ErrGameExists, http.StatusConflict, `{"type":"already_exists","message":"Another resource has the same value as this field"}`},
+		{memory.ErrInvalidCursor, http.StatusUnprocessableEntity, `{"type":"invalid_request","message":"One or more fields are invalid","details":[{"field":"cursor","constraint":"must come from a previous listing"}]}`},
+		{memory.ErrConflict, http.StatusConflict, `{"type":"conflict","message":"Game was changed by another request, try again"}`},
+		{minesweeper.ErrVersionMismatch, http.StatusPreconditionFailed, `{"type":"precondition_failed","message":"Game does not match the If-Match version"}`},
+		{&minesweeper.ValidationError{Fields: []minesweeper.FieldError{{Field: "row", Constraint: "must be between 0 and 5"}}}, http.StatusUnprocessableEntity, `{"type":"invalid_request","message":"One or more fields are invalid","details":[{"field":"row","constraint":"must be between 0 and 5"}]}`},
+		{minesweeper.ErrCellClicked, http.StatusConflict, `{"type":"cell_clicked","message":"Cell already clicked"}`},
+		{minesweeper.ErrCellFlagged, http.StatusConflict, `{"type":"cell_flagged","message":"Cell is flagged"}`},
+		{minesweeper.ErrFlagsMismatch, http.StatusUnprocessableEntity, `{"type":"invalid_chord","message":"adjacent flags do not match cell value"}`},
+		{&minesweeper.StateError{Action: minesweeper.ActionClick, Status: types.StatusOver}, http.StatusConflict, `{"type":"invalid_state","message":"cannot click a game with status \"over\""}`},
+		{errors.New("some error"), http.StatusInternalServerError, `{"type":"server_error","message":"Internal server error. The error has been logged and we are working on it"}`},
+	}
+
+	for _, tt := range tests {
+		services := &Services{
+			logger: log,
+			GameService: &mocks.MockGameService{
+				OnCreate: func(game *types.Game) error {
+					return tt.err
+				},
+				OnClick: func(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+					return nil, nil, tt.err
+				},
+			},
+		}
+
+		requests := []struct {
+			path string
+			data string
+		}{
+			{"/game", `{"name":"teste"}`},
+			{"/game/teste/click", `{"row": 0, "col": 0}`},
+		}
+		for _, r := range requests {
+			req, err := http.NewRequest("POST", r.path, strings.NewReader(r.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			Router(services).ServeHTTP(rr, req)
+
+			// Check the status code.
+			if status := rr.Code; status != tt.status {
+				t.Errorf("%s: %v: handler returned wrong status code: want %v, got %v",
+					r.path, tt.err, tt.status, status)
+			}
+
+			// Check the response body.
+			if !strings.Contains(rr.Body.String(), tt.expected) {
+				t.Errorf("%s: %v: handler returned unexpected body: want %v, got %v",
+					r.path, tt.err, tt.expected, rr.Body.String())
+			}
+		}
+	}
+}
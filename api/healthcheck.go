@@ -1,6 +1,10 @@
 package api
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/guilhermebr/minesweeper/minesweeper"
+)
 
 // title: healthcheck
 // path: /healthcheck
@@ -10,3 +14,24 @@ import "net/http"
 func (s *Services) healthcheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
+
+// title: metrics
+// path: /metrics
+// method: GET
+// responses:
+//   200: OK
+func (s *Services) metrics(w http.ResponseWriter, r *http.Request) {
+	var result struct {
+		Janitor  minesweeper.JanitorStats
+		Timeouts minesweeper.TimeoutStats
+	}
+
+	if s.Janitor != nil {
+		result.Janitor = s.Janitor.Stats()
+	}
+	if s.Timeouts != nil {
+		result.Timeouts = s.Timeouts.Stats()
+	}
+
+	Success(&result, http.StatusOK).Send(w)
+}
@@ -0,0 +1,70 @@
+package minesweeper
+
+import (
+	"time"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// Elapsed returns the time played on game by now, leaving out the time it
+// was paused. The clock only runs from the first reveal until the game ends.
+func Elapsed(game *types.Game, now time.Time) time.Duration {
+	if game.StartedAt == nil {
+		return 0
+	}
+
+	end := now
+	switch {
+	case game.EndedAt != nil:
+		end = *game.EndedAt
+	case game.PausedAt != nil:
+		end = *game.PausedAt
+	}
+	return end.Sub(*game.StartedAt) - game.PausedFor
+}
+
+// tick runs the clock of game after move: the first reveal starts it and the
+// move that ends the game stops it.
+func tick(game *types.Game, move types.Move) {
+	if game.StartedAt == nil && move.Kind == types.MoveReveal {
+		at := move.At
+		game.StartedAt = &at
+	}
+	if Finished(game.Status) {
+		stopClock(game, move.At)
+	}
+}
+
+// stopClock stops the clock of game at at, ending a pause in progress.
+func stopClock(game *types.Game, at time.Time) {
+	if game.StartedAt == nil || game.EndedAt != nil {
+		return
+	}
+	resumeClock(game, at)
+	game.EndedAt = &at
+}
+
+// pauseClock stops counting the time played on game from at.
+func pauseClock(game *types.Game, at time.Time) {
+	if game.StartedAt == nil || game.PausedAt != nil {
+		return
+	}
+	game.PausedAt = &at
+}
+
+// resumeClock counts the time played on game again from at.
+func resumeClock(game *types.Game, at time.Time) {
+	if game.PausedAt == nil {
+		return
+	}
+	game.PausedFor += at.Sub(*game.PausedAt)
+	game.PausedAt = nil
+}
+
+// resetClock sets the clock of game back to zero.
+func resetClock(game *types.Game) {
+	game.StartedAt = nil
+	game.EndedAt = nil
+	game.PausedAt = nil
+	game.PausedFor = 0
+}
@@ -0,0 +1,19 @@
+package minesweeper
+
+import "errors"
+
+// Errors returned by GameService when a request cannot be fulfilled.
+var (
+	ErrCellClicked    = errors.New("cell already clicked")
+	ErrCellFlagged    = errors.New("cell is flagged")
+	ErrCellNotClicked = errors.New("cell not clicked")
+	ErrNothingToChord = errors.New("cell has no adjacent mines")
+	ErrFlagsMismatch  = errors.New("adjacent flags do not match cell value")
+	ErrNoCellLeft     = errors.New("no cell left to open")
+	ErrRankedGame     = errors.New("undo and restart are disabled on ranked games")
+	ErrNoMoveToUndo   = errors.New("no move to undo")
+
+	// ErrVersionMismatch is returned when the game is not at the version
+	// the caller expected.
+	ErrVersionMismatch = errors.New("game version does not match")
+)
@@ -1,29 +1,51 @@
 package minesweeper
 
 import (
-	"errors"
-	"fmt"
+	"time"
 
+	"github.com/guilhermebr/minesweeper/solver"
 	"github.com/guilhermebr/minesweeper/types"
 )
 
 type GameService struct {
 	Store types.GameStore
+
+	// NoGuessBudget bounds the time spent looking for a guess free layout
+	// on the first click of no guess games. Zero means defaultNoGuessBudget.
+	NoGuessBudget time.Duration
+
+	// Now returns the current time. Nil means time.Now.
+	Now func() time.Time
+
+	// Timeouts ends games when their time limit runs out. Nil leaves them
+	// running until they are next played.
+	Timeouts *Timeouts
+
+	// Presets adds difficulties to DefaultPresets, or replaces those with
+	// the same name.
+	Presets map[string]Preset
+
+	locks gameLocks
 }
 
 const (
-	defaultRows  = 6
-	defaultCols  = 6
-	defaultMines = 12
-	maxRows      = 30
-	maxCols      = 30
+	defaultRows          = 6
+	defaultCols          = 6
+	defaultMines         = 12
+	maxRows              = 30
+	maxCols              = 30
+	maxDensity           = 0.5
+	defaultNoGuessBudget = 2 * time.Second
+	defaultPageSize      = 20
+	maxPageSize          = 100
 )
 
 func (s *GameService) Create(game *types.Game) error {
-	if game.Name == "" {
-		return errors.New("no Game name")
+	if game.Difficulty != "" {
+		if err := s.applyPreset(game); err != nil {
+			return err
+		}
 	}
-
 	if game.Rows == 0 {
 		game.Rows = defaultRows
 	}
@@ -33,49 +55,591 @@ func (s *GameService) Create(game *types.Game) error {
 	if game.Mines == 0 {
 		game.Mines = defaultMines
 	}
-
-	if game.Rows > maxRows {
-		game.Rows = maxRows
+	if game.FirstClick == "" {
+		game.FirstClick = types.FirstClickSafe
+		if game.NoGuess {
+			game.FirstClick = types.FirstClickOpening
+		}
 	}
-	if game.Cols > maxCols {
-		game.Cols = maxCols
+
+	if err := validateGame(game); err != nil {
+		return err
 	}
-	if game.Mines > (game.Cols * game.Rows) {
-		game.Mines = (game.Cols * game.Rows)
+	if game.Seed == 0 {
+		game.Seed = time.Now().UnixNano()
 	}
-	game.Status = "new"
+
+	// The board and the play so far are owned by the server, whatever the
+	// request held.
+	game.RemainingMines = game.Mines
+	game.Status = types.StatusNew
+	game.Grid = nil
+	game.Clicks = 0
+	game.MinesPending = false
+	game.GuessFree = false
+	game.Parent = ""
+	game.Hints = 0
+	game.Moves = nil
+	game.Undos = 0
+	resetClock(game)
+	game.Elapsed = 0
+	game.CreatedAt = s.now()
+	game.UpdatedAt = game.CreatedAt
 
 	err := s.Store.Insert(game)
 	return err
 }
 
-func (s *GameService) Start(name string) (*types.Game, error) {
-	game, err := s.Store.GetByName(name)
+// applyPreset sizes game after the preset named by its difficulty.
+func (s *GameService) applyPreset(game *types.Game) error {
+	v := &ValidationError{}
+
+	preset, ok := s.preset(game.Difficulty)
+	switch {
+	case !ok:
+		v.add("difficulty", "must be one of %s", s.presetNames())
+	case game.Rows != 0 || game.Cols != 0 || game.Mines != 0:
+		v.add("difficulty", "cannot be combined with rows, cols or mines")
+	default:
+		game.Rows = preset.Rows
+		game.Cols = preset.Cols
+		game.Mines = preset.Mines
+	}
+
+	return v.err()
+}
+
+func (s *GameService) Start(name string, version int64) (*types.Game, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
 	if err != nil {
 		return nil, err
 	}
 
-	buildBoard(game)
+	if err := checkVersion(game, version); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionStart); err != nil {
+		return nil, err
+	}
 
-	game.Status = "started"
-	err = s.Store.Update(game)
-	fmt.Printf("%#v\n", game.Grid)
+	if game.FirstClick == types.FirstClickOff {
+		buildBoard(game)
+	} else {
+		// Mines are laid on the first click so it can be kept safe.
+		newGrid(game)
+		game.MinesPending = true
+	}
+
+	if err := setStatus(game, types.StatusStarted); err != nil {
+		return nil, err
+	}
+	err = s.update(game)
 	return game, err
 }
 
-func (s *GameService) Click(name string, i, j int) (*types.Game, error) {
+func (s *GameService) Click(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkAction(game, ActionClick); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateCell(game, i, j); err != nil {
+		return nil, nil, err
+	}
+
+	if game.MinesPending {
+		if game.NoGuess {
+			budget := s.NoGuessBudget
+			if budget == 0 {
+				budget = defaultNoGuessBudget
+			}
+			game.GuessFree = placeMinesNoGuess(game, i, j, budget)
+		} else {
+			placeMines(game, safeCells(game, i, j))
+		}
+		game.MinesPending = false
+	}
+
+	revealed, err := clickCell(game, i, j)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.record(game, types.MoveReveal, i, j, revealed)
+
+	if err := s.update(game); err != nil {
+		return nil, nil, err
+	}
+
+	return game, revealed, nil
+}
+
+// Chord opens the unflagged neighbours of the revealed cell at i, j when its
+// adjacent flags match its value.
+func (s *GameService) Chord(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkAction(game, ActionChord); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateCell(game, i, j); err != nil {
+		return nil, nil, err
+	}
+
+	revealed, err := chordCell(game, i, j)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.record(game, types.MoveChord, i, j, revealed)
+
+	if err := s.update(game); err != nil {
+		return nil, nil, err
+	}
+
+	return game, revealed, nil
+}
+
+// Flag cycles the mark of the cell at i, j through flag, question and none.
+func (s *GameService) Flag(name string, i, j int, version int64) (*types.Game, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionFlag); err != nil {
+		return nil, err
+	}
+
+	if err := validateCell(game, i, j); err != nil {
+		return nil, err
+	}
+
+	if err := markCell(game, i, j); err != nil {
+		return nil, err
+	}
+	s.record(game, types.MoveFlag, i, j, []types.Position{{Row: i, Col: j}})
+
+	if err := s.update(game); err != nil {
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Hint suggests the next cell to open from what the player can see, and
+// counts it on the game.
+func (s *GameService) Hint(name string, version int64) (*types.Game, *types.Hint, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkAction(game, ActionHint); err != nil {
+		return nil, nil, err
+	}
+
+	var hint *types.Hint
+	if game.MinesPending {
+		hint = &types.Hint{
+			Position: types.Position{Row: game.Rows / 2, Col: game.Cols / 2},
+			Safe:     true,
+			Reason:   "the first click never hits a mine",
+		}
+	} else {
+		hint = solver.Hint(game)
+	}
+	if hint == nil {
+		return nil, nil, ErrNoCellLeft
+	}
+
+	game.Hints++
+	if err := s.update(game); err != nil {
+		return nil, nil, err
+	}
+
+	return game, hint, nil
+}
+
+// Surrender gives up the game called name, laying its mines when they are not
+// on the board yet so the solution can be shown.
+func (s *GameService) Surrender(name string, version int64) (*types.Game, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionSurrender); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case game.Status == types.StatusNew:
+		buildBoard(game)
+	case game.MinesPending:
+		placeMines(game, nil)
+		game.MinesPending = false
+	}
+
+	if err := setStatus(game, types.StatusSurrendered); err != nil {
+		return nil, err
+	}
+	stopClock(game, s.now())
+
+	if err := s.update(game); err != nil {
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Restart closes every cell of the game called name and plays it again on
+// the same mines. Ranked games cannot be restarted, as their mines may
+// already be known.
+func (s *GameService) Restart(name string, version int64) (*types.Game, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionRestart); err != nil {
+		return nil, err
+	}
+
+	if game.Ranked {
+		return nil, ErrRankedGame
+	}
+
+	resetBoard(game)
+	resetClock(game)
+	// Hints stay counted, as undos do, so restarting does not clear the
+	// penalty they carry.
+	game.Moves = nil
+	// A restart begins a new round rather than moving the game forward, so
+	// it bypasses the transitions table, as Create does.
+	game.Status = types.StatusStarted
+
+	if err := s.update(game); err != nil {
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Undo takes back the last moves of the game called name, a losing click
+// included, by playing the earlier ones again on the same mines. Ranked games
+// cannot be undone.
+func (s *GameService) Undo(name string, moves int, version int64) (*types.Game, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionUndo); err != nil {
+		return nil, err
+	}
+
+	if game.Ranked {
+		return nil, ErrRankedGame
+	}
+	if len(game.Moves) == 0 {
+		return nil, ErrNoMoveToUndo
+	}
+	if err := validateUndo(game, moves); err != nil {
+		return nil, err
+	}
+
+	kept := game.Moves[:len(game.Moves)-moves]
+	resetBoard(game)
+	// Undoing rewinds the game rather than moving it forward, so it bypasses
+	// the transitions table, as Restart does. The clock keeps the time paused
+	// and runs again from the first reveal kept.
+	game.Status = types.StatusStarted
+	pausedFor := game.PausedFor
+	resetClock(game)
+	for _, move := range kept {
+		if err := playMove(game, move); err != nil {
+			return nil, err
+		}
+		tick(game, move)
+	}
+	if game.StartedAt != nil {
+		game.PausedFor = pausedFor
+	}
+	game.Moves = kept
+	game.Undos++
+
+	if err := s.update(game); err != nil {
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Pause stops the clock of the game called name and hides its board until it
+// is resumed.
+func (s *GameService) Pause(name string, version int64) (*types.Game, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionPause); err != nil {
+		return nil, err
+	}
+
+	if err := setStatus(game, types.StatusPaused); err != nil {
+		return nil, err
+	}
+	pauseClock(game, s.now())
+
+	if err := s.update(game); err != nil {
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Resume starts the clock of the paused game called name again.
+func (s *GameService) Resume(name string, version int64) (*types.Game, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionResume); err != nil {
+		return nil, err
+	}
+
+	if err := setStatus(game, types.StatusStarted); err != nil {
+		return nil, err
+	}
+	resumeClock(game, s.now())
+
+	if err := s.update(game); err != nil {
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Replay returns the replay of the finished game called name.
+func (s *GameService) Replay(name string) (*types.Replay, error) {
 	game, err := s.Store.GetByName(name)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := clickCell(game, i, j); err != nil {
+	if err := checkAction(game, ActionReplay); err != nil {
 		return nil, err
 	}
 
-	if err := s.Store.Update(game); err != nil {
+	return Export(game), nil
+}
+
+// Fork copies the game called name, in its current state, to a new game
+// called newName. Ranked games can only be forked once finished, as the copy
+// could be surrendered to learn where the mines are, and their forks are
+// practice games.
+func (s *GameService) Fork(name, newName string) (*types.Game, error) {
+	game, err := s.Store.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Ranked && !Finished(game.Status) {
+		return nil, &StateError{Action: ActionFork, Status: game.Status}
+	}
+
+	if err := validateFork(newName); err != nil {
+		return nil, err
+	}
+
+	game.Name = newName
+	game.Parent = name
+	game.Ranked = false
+	game.CreatedAt = s.now()
+	game.UpdatedAt = game.CreatedAt
+	game.Elapsed = Elapsed(game, game.CreatedAt)
+
+	if err := s.Store.Insert(game); err != nil {
+		return nil, err
+	}
+	s.Timeouts.track(game)
+
+	return game, nil
+}
+
+// Delete removes the game called name, whatever its status.
+func (s *GameService) Delete(name string, version int64) error {
+	defer s.locks.lock(name)()
+
+	game, err := s.Store.GetByName(name)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion(game, version); err != nil {
+		return err
+	}
+
+	if err := s.Store.Delete(name); err != nil {
+		return err
+	}
+	s.Timeouts.cancel(name)
+	return nil
+}
+
+// Get returns the game called name.
+func (s *GameService) Get(name string) (*types.Game, error) {
+	game, err := s.Store.GetByName(name)
+	if err != nil {
 		return nil, err
 	}
 
+	game.Elapsed = Elapsed(game, s.now())
 	return game, nil
 }
+
+// List returns a page of the games matching query, at most defaultPageSize
+// games when query has no limit.
+func (s *GameService) List(query types.GameQuery) (*types.GamePage, error) {
+	if err := validateQuery(query); err != nil {
+		return nil, err
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+
+	page, err := s.Store.List(query)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for _, game := range page.Games {
+		game.Elapsed = Elapsed(game, now)
+	}
+	return page, nil
+}
+
+// Probabilities returns the chance of every unopened cell hiding a mine from
+// what the player can see.
+func (s *GameService) Probabilities(name string) (*types.Probabilities, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAction(game, ActionProbabilities); err != nil {
+		return nil, err
+	}
+
+	return solver.Probabilities(game), nil
+}
+
+// checkVersion fails with ErrVersionMismatch when the caller expects game to
+// be at another version. A zero version matches any.
+func checkVersion(game *types.Game, version int64) error {
+	if version != 0 && version != game.Version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// record appends a move of the player on the cell at i, j to the log of game
+// and runs its clock.
+func (s *GameService) record(game *types.Game, kind types.MoveKind, i, j int, cells []types.Position) {
+	move := types.Move{
+		Kind:     kind,
+		Position: types.Position{Row: i, Col: j},
+		At:       s.now(),
+		Cells:    cells,
+	}
+	game.Moves = append(game.Moves, move)
+	tick(game, move)
+}
+
+// update stores game, recording the change as its last activity, and keeps
+// track of its deadline.
+func (s *GameService) update(game *types.Game) error {
+	game.UpdatedAt = s.now()
+	game.Elapsed = Elapsed(game, game.UpdatedAt)
+	if err := s.Store.Update(game); err != nil {
+		return err
+	}
+	s.Timeouts.track(game)
+	return nil
+}
+
+func (s *GameService) now() time.Time {
+	if s.Now != nil {
+		return s.Now().UTC()
+	}
+	return time.Now().UTC()
+}
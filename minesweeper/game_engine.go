@@ -1,36 +1,54 @@
 package minesweeper
 
 import (
-	"errors"
+	"fmt"
 	"math/rand"
-	"time"
 
 	"github.com/guilhermebr/minesweeper/types"
 )
 
-func init() {
-	rand.Seed(time.Now().Unix())
+func buildBoard(game *types.Game) {
+	newGrid(game)
+	placeMines(game, nil)
 }
 
-func buildBoard(game *types.Game) {
-	numCells := game.Cols * game.Rows
-	cells := make(types.CellGrid, numCells)
+// newGrid sets an empty board, without mines, on game.
+func newGrid(game *types.Game) {
+	cells := make(types.CellGrid, game.Cols*game.Rows)
+
+	game.Grid = make([]types.CellGrid, game.Rows)
+	for row := range game.Grid {
+		game.Grid[row] = cells[(game.Cols * row):(game.Cols * (row + 1))]
+	}
+}
+
+// placeMines randomly lays game.Mines mines on an empty board, keeping the
+// cells in safe free of mines, and sets the cell values. The layout only
+// depends on the game seed, dimensions and safe cells.
+func placeMines(game *types.Game, safe []types.Position) {
+	layMines(game, rand.New(rand.NewSource(game.Seed)), safe)
+}
+
+func layMines(game *types.Game, rng *rand.Rand, safe []types.Position) {
+	excluded := make(map[types.Position]bool, len(safe))
+	for _, pos := range safe {
+		excluded[pos] = true
+	}
 
 	// Randomly set mines
 	i := 0
 	for i < game.Mines {
-		idx := rand.Intn(numCells)
-		if !cells[idx].Mine {
-			cells[idx].Mine = true
+		idx := rng.Intn(game.Rows * game.Cols)
+		pos := types.Position{Row: idx / game.Cols, Col: idx % game.Cols}
+		if excluded[pos] {
+			continue
+		}
+		if !game.Grid[pos.Row][pos.Col].Mine {
+			game.Grid[pos.Row][pos.Col].Mine = true
 			i++
 		}
 	}
 
-	game.Grid = make([]types.CellGrid, game.Rows)
-	for row := range game.Grid {
-		game.Grid[row] = cells[(game.Cols * row):(game.Cols * (row + 1))]
-	}
-
 	// Set cell values
 	for i, row := range game.Grid {
 		for j, cell := range row {
@@ -41,7 +59,38 @@ func buildBoard(game *types.Game) {
 	}
 }
 
+// safeCells returns the cells that must be kept free of mines when the first
+// click is on i, j. Protection is reduced when the board is too crowded to
+// fit every mine outside of it.
+func safeCells(game *types.Game, i, j int) []types.Position {
+	safe := []types.Position{{Row: i, Col: j}}
+	free := game.Rows*game.Cols - game.Mines
+
+	if game.FirstClick == types.FirstClickOpening {
+		opening := safe
+		forEachNeighbour(game, i, j, func(z, w int) {
+			opening = append(opening, types.Position{Row: z, Col: w})
+		})
+		if len(opening) <= free {
+			return opening
+		}
+	}
+
+	if len(safe) <= free {
+		return safe
+	}
+	return nil
+}
+
 func setAdjacentValues(game *types.Game, i, j int) {
+	forEachNeighbour(game, i, j, func(z, w int) {
+		game.Grid[z][w].Value++
+	})
+}
+
+// forEachNeighbour calls fn with the position of every cell adjacent to i, j
+// that lies inside the board.
+func forEachNeighbour(game *types.Game, i, j int, fn func(z, w int)) {
 	for z := i - 1; z < i+2; z++ {
 		if z < 0 || z > game.Rows-1 {
 			continue
@@ -53,28 +102,169 @@ func setAdjacentValues(game *types.Game, i, j int) {
 			if z == i && w == j {
 				continue
 			}
-			game.Grid[z][w].Value++
+			fn(z, w)
 		}
 	}
 }
 
-func clickCell(game *types.Game, i, j int) error {
+// clickCell reveals the cell at i, j and returns the position of every cell
+// opened by the click.
+func clickCell(game *types.Game, i, j int) ([]types.Position, error) {
 	if game.Grid[i][j].Clicked {
-		return errors.New("cell already clicked")
+		return nil, ErrCellClicked
+	}
+	if game.Grid[i][j].Mark == types.MarkFlag {
+		return nil, ErrCellFlagged
 	}
-	game.Grid[i][j].Clicked = true
 	if game.Grid[i][j].Mine {
-		game.Status = "over"
-		return nil
+		if err := setStatus(game, types.StatusOver); err != nil {
+			return nil, err
+		}
+		game.Grid[i][j].Clicked = true
+		return []types.Position{{Row: i, Col: j}}, nil
 	}
-	game.Clicks += 1
+
+	revealed := revealCells(game, i, j)
 	if checkWon(game) {
-		game.Status = "won"
+		if err := setStatus(game, types.StatusWon); err != nil {
+			return nil, err
+		}
 	}
 
+	return revealed, nil
+}
+
+// revealCells opens the cell at i, j. When the cell has no adjacent mines the
+// whole connected region of zero cells is opened together with its numbered
+// border, the same way a player would by clicking each of them. Flagged cells
+// are left closed.
+func revealCells(game *types.Game, i, j int) []types.Position {
+	game.Grid[i][j].Clicked = true
+	game.Grid[i][j].Mark = types.MarkNone
+	queue := []types.Position{{Row: i, Col: j}}
+
+	var revealed []types.Position
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+
+		game.Clicks++
+		revealed = append(revealed, pos)
+		if game.Grid[pos.Row][pos.Col].Value != 0 {
+			continue
+		}
+
+		forEachNeighbour(game, pos.Row, pos.Col, func(z, w int) {
+			cell := &game.Grid[z][w]
+			if cell.Clicked || cell.Mine || cell.Mark == types.MarkFlag {
+				return
+			}
+			cell.Clicked = true
+			cell.Mark = types.MarkNone
+			queue = append(queue, types.Position{Row: z, Col: w})
+		})
+	}
+	return revealed
+}
+
+// chordCell opens every unflagged neighbour of the revealed cell at i, j once
+// the number of flags around it matches its value, and returns the position
+// of every cell opened. A misplaced flag ends the game.
+func chordCell(game *types.Game, i, j int) ([]types.Position, error) {
+	cell := game.Grid[i][j]
+	if !cell.Clicked {
+		return nil, ErrCellNotClicked
+	}
+	if cell.Mine || cell.Value == 0 {
+		return nil, ErrNothingToChord
+	}
+
+	flags := 0
+	forEachNeighbour(game, i, j, func(z, w int) {
+		if game.Grid[z][w].Mark == types.MarkFlag {
+			flags++
+		}
+	})
+	if flags != cell.Value {
+		return nil, ErrFlagsMismatch
+	}
+
+	var revealed []types.Position
+	exploded := false
+	forEachNeighbour(game, i, j, func(z, w int) {
+		neighbour := &game.Grid[z][w]
+		if neighbour.Clicked || neighbour.Mark == types.MarkFlag {
+			return
+		}
+		if neighbour.Mine {
+			neighbour.Clicked = true
+			exploded = true
+			revealed = append(revealed, types.Position{Row: z, Col: w})
+			return
+		}
+		revealed = append(revealed, revealCells(game, z, w)...)
+	})
+
+	var err error
+	if exploded {
+		err = setStatus(game, types.StatusOver)
+	} else if checkWon(game) {
+		err = setStatus(game, types.StatusWon)
+	}
+
+	return revealed, err
+}
+
+// markCell cycles the mark of the cell at i, j from none to flag, from flag
+// to question and from question back to none, keeping the remaining mines
+// counter in sync with the flags on the board.
+func markCell(game *types.Game, i, j int) error {
+	cell := &game.Grid[i][j]
+	if cell.Clicked {
+		return ErrCellClicked
+	}
+
+	switch cell.Mark {
+	case types.MarkNone:
+		cell.Mark = types.MarkFlag
+		game.RemainingMines--
+	case types.MarkFlag:
+		cell.Mark = types.MarkQuestion
+		game.RemainingMines++
+	default:
+		cell.Mark = types.MarkNone
+	}
 	return nil
 }
 
+// resetBoard closes and unmarks every cell of game, keeping its mines.
+func resetBoard(game *types.Game) {
+	for i := range game.Grid {
+		for j := range game.Grid[i] {
+			game.Grid[i][j].Clicked = false
+			game.Grid[i][j].Mark = types.MarkNone
+		}
+	}
+	game.Clicks = 0
+	game.RemainingMines = game.Mines
+}
+
+// playMove plays move on game again, the same way the player did.
+func playMove(game *types.Game, move types.Move) error {
+	var err error
+	switch move.Kind {
+	case types.MoveReveal:
+		_, err = clickCell(game, move.Row, move.Col)
+	case types.MoveChord:
+		_, err = chordCell(game, move.Row, move.Col)
+	case types.MoveFlag:
+		err = markCell(game, move.Row, move.Col)
+	default:
+		err = fmt.Errorf("unknown move %q", move.Kind)
+	}
+	return err
+}
+
 func checkWon(game *types.Game) bool {
 	return game.Clicks == ((game.Rows * game.Cols) - game.Mines)
 }
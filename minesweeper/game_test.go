@@ -1,11 +1,13 @@
 package minesweeper
 
 import (
-	"math/rand"
+	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/guilhermebr/minesweeper/mocks"
+	"github.com/guilhermebr/minesweeper/storage/memory"
 	"github.com/guilhermebr/minesweeper/types"
 )
 
@@ -22,6 +24,7 @@ func TestCreateGame(t *testing.T) {
 		Cols:  10,
 		Rows:  11,
 		Mines: 12,
+		Hints: -7,
 	}
 
 	if err := s.Create(game); err != nil {
@@ -38,7 +41,13 @@ func TestCreateGame(t *testing.T) {
 		t.Errorf("unexpected mines. want=12, got %d", game.Mines)
 	}
 	if game.Status != "new" {
-		t.Errorf("unexpected status. want='new', got %d", game.Status)
+		t.Errorf("unexpected status. want='new', got %s", game.Status)
+	}
+	if game.CreatedAt.IsZero() {
+		t.Error("creation time should be set")
+	}
+	if game.Hints != 0 {
+		t.Errorf("unexpected hints. want=0, got %d", game.Hints)
 	}
 }
 func TestCreateGame_Default(t *testing.T) {
@@ -68,35 +77,196 @@ func TestCreateGame_Default(t *testing.T) {
 	}
 }
 
-func TestCreateGame_MaxValues(t *testing.T) {
+func TestCreateGame_Preset(t *testing.T) {
 	s := GameService{
 		Store: &mocks.MockGameStore{
 			OnInsert: func(game *types.Game) error {
 				return nil
 			},
 		},
+		Presets: map[string]Preset{
+			"beginner": {Rows: 8, Cols: 8, Mines: 10},
+			"tiny":     {Rows: 4, Cols: 4, Mines: 2},
+		},
 	}
-	game := &types.Game{
-		Name:  "mygame",
-		Cols:  9999,
-		Rows:  9999,
-		Mines: 9999,
+
+	tests := []struct {
+		difficulty string
+		preset     Preset
+	}{
+		{"beginner", Preset{Rows: 8, Cols: 8, Mines: 10}},
+		{"intermediate", Preset{Rows: 16, Cols: 16, Mines: 40}},
+		{"expert", Preset{Rows: 16, Cols: 30, Mines: 99}},
+		{"tiny", Preset{Rows: 4, Cols: 4, Mines: 2}},
 	}
+	for _, tt := range tests {
+		game := &types.Game{Name: "mygame", Difficulty: tt.difficulty}
+		if err := s.Create(game); err != nil {
+			t.Fatalf("%s: %v", tt.difficulty, err)
+		}
 
-	rand.Seed(1)
+		got := Preset{Rows: game.Rows, Cols: game.Cols, Mines: game.Mines}
+		if got != tt.preset {
+			t.Errorf("%s: unexpected board. want=%+v, got=%+v", tt.difficulty, tt.preset, got)
+		}
+	}
 
-	if err := s.Create(game); err != nil {
+	err := s.Create(&types.Game{Name: "mygame", Difficulty: "insane"})
+	want := `invalid request: difficulty must be one of "beginner", "expert", "intermediate", "tiny"`
+	if err == nil || err.Error() != want {
+		t.Errorf("unexpected error. want=%s, got=%v", want, err)
+	}
+}
+
+func TestValidatePresets(t *testing.T) {
+	if err := ValidatePresets(DefaultPresets); err != nil {
+		t.Errorf("default presets should be valid, got=%v", err)
+	}
+
+	err := ValidatePresets(map[string]Preset{
+		"huge":    {Rows: 50, Cols: 50, Mines: 10},
+		"crowded": {Rows: 4, Cols: 4, Mines: 12},
+		"ok":      {Rows: 4, Cols: 4, Mines: 2},
+	})
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("unexpected error. want=*ValidationError, got=%v", err)
+	}
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	if want := []string{"crowded.mines", "huge.rows", "huge.cols"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("unexpected fields. want=%v, got=%v", want, fields)
+	}
+}
+
+func TestCreateGame_Invalid(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnInsert: func(game *types.Game) error {
+				t.Fatal("invalid game should not be inserted")
+				return nil
+			},
+		},
+	}
+
+	tests := []struct {
+		game   types.Game
+		fields []string
+	}{
+		{types.Game{Name: ""}, []string{"name"}},
+		{types.Game{Name: "my game"}, []string{"name"}},
+		{types.Game{Name: "mygame", Owner: "bob/alice"}, []string{"owner"}},
+		{types.Game{Name: "mygame", Cols: 9999, Rows: 9999, Mines: 9999}, []string{"rows", "cols"}},
+		{types.Game{Name: "mygame", Rows: -1}, []string{"rows"}},
+		{types.Game{Name: "mygame", Rows: 2, Cols: 2, Mines: 4}, []string{"mines"}},
+		{types.Game{Name: "mygame", Mines: -3}, []string{"mines"}},
+		{types.Game{Name: "mygame", FirstClick: "maybe"}, []string{"first_click"}},
+		{types.Game{Name: "mygame", NoGuess: true, FirstClick: types.FirstClickOff}, []string{"no_guess"}},
+		{types.Game{Name: "mygame", Rows: 4, Cols: 4, Mines: 10}, []string{"mines"}},
+		{types.Game{Name: "mygame", Rows: 4, Cols: 4, Mines: 8, FirstClick: types.FirstClickOpening}, []string{"mines"}},
+		{types.Game{Name: "mygame", Difficulty: "insane"}, []string{"difficulty"}},
+		{types.Game{Name: "mygame", Difficulty: "expert", Mines: 10}, []string{"difficulty"}},
+		{types.Game{Name: "mygame", Ranked: true, Seed: 42}, []string{"seed"}},
+	}
+	for _, tt := range tests {
+		game := tt.game
+		err := s.Create(&game)
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("%+v: unexpected error. want=*ValidationError, got=%v", tt.game, err)
+			continue
+		}
+
+		var fields []string
+		for _, f := range verr.Fields {
+			fields = append(fields, f.Field)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%+v: unexpected fields. want=%v, got=%v", tt.game, tt.fields, fields)
+		}
+	}
+}
+
+func TestListGames(t *testing.T) {
+	var got types.GameQuery
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnList: func(query types.GameQuery) (*types.GamePage, error) {
+				got = query
+				return &types.GamePage{}, nil
+			},
+		},
+	}
+
+	if _, err := s.List(types.GameQuery{Owner: "bob"}); err != nil {
 		t.Fatal(err)
 	}
+	if got.Owner != "bob" || got.Limit != defaultPageSize {
+		t.Errorf("unexpected query. want=owner bob and limit %d, got=%+v", defaultPageSize, got)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		query  types.GameQuery
+		fields []string
+	}{
+		{types.GameQuery{Status: "lost"}, []string{"status"}},
+		{types.GameQuery{Rows: -1, Cols: -1}, []string{"rows", "cols"}},
+		{types.GameQuery{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}, []string{"created_before"}},
+		{types.GameQuery{Limit: maxPageSize + 1}, []string{"limit"}},
+	}
+	for _, tt := range tests {
+		_, err := s.List(tt.query)
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("%+v: unexpected error. want=*ValidationError, got=%v", tt.query, err)
+			continue
+		}
 
-	if game.Cols != maxCols {
-		t.Errorf("unexpected cols. want=%d, got %d", maxCols, game.Cols)
+		var fields []string
+		for _, f := range verr.Fields {
+			fields = append(fields, f.Field)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%+v: unexpected fields. want=%v, got=%v", tt.query, tt.fields, fields)
+		}
 	}
-	if game.Rows != maxRows {
-		t.Errorf("unexpected rows. want=%d, got %d", maxRows, game.Rows)
+}
+
+func TestClickCell_OutOfBounds(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				return &types.Game{
+					Name:   name,
+					Cols:   2,
+					Rows:   2,
+					Mines:  1,
+					Status: types.StatusStarted,
+					Grid:   []types.CellGrid{make(types.CellGrid, 2), make(types.CellGrid, 2)},
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				t.Fatal("game should not be updated")
+				return nil
+			},
+		},
 	}
-	if game.Mines != (maxCols * maxRows) {
-		t.Errorf("unexpected mines. want=%d, got %d", (maxCols * maxRows), game.Mines)
+
+	for _, pos := range []types.Position{{Row: -1, Col: 0}, {Row: 0, Col: 2}, {Row: 5, Col: -5}} {
+		if _, _, err := s.Click("test", pos.Row, pos.Col, 0); err == nil {
+			t.Errorf("click on %v should fail", pos)
+		} else if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("click on %v: unexpected error. want=*ValidationError, got=%v", pos, err)
+		}
+		if _, _, err := s.Chord("test", pos.Row, pos.Col, 0); err == nil {
+			t.Errorf("chord on %v should fail", pos)
+		}
+		if _, err := s.Flag("test", pos.Row, pos.Col, 0); err == nil {
+			t.Errorf("flag on %v should fail", pos)
+		}
 	}
 }
 
@@ -105,10 +275,13 @@ func TestStartGame(t *testing.T) {
 		Store: &mocks.MockGameStore{
 			OnGetByName: func(name string) (*types.Game, error) {
 				return &types.Game{
-					Name:  name,
-					Cols:  2,
-					Rows:  2,
-					Mines: 1,
+					Name:       name,
+					Cols:       2,
+					Rows:       2,
+					Mines:      1,
+					Seed:       1,
+					FirstClick: types.FirstClickOff,
+					Status:     types.StatusNew,
 				}, nil
 			},
 			OnUpdate: func(game *types.Game) error {
@@ -117,13 +290,13 @@ func TestStartGame(t *testing.T) {
 		},
 	}
 
-	game, err := s.Start("mygame")
+	game, err := s.Start("mygame", 0)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	if game.Status != "started" {
-		t.Errorf("unexpected status. want='started', got %d", game.Status)
+		t.Errorf("unexpected status. want='started', got %s", game.Status)
 	}
 
 	expected := []types.CellGrid{
@@ -141,6 +314,148 @@ func TestStartGame(t *testing.T) {
 	}
 }
 
+func TestCreateGame_Seed(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnInsert: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game := &types.Game{Name: "mygame"}
+	if err := s.Create(game); err != nil {
+		t.Fatal(err)
+	}
+	if game.Seed == 0 {
+		t.Error("a seed should be generated when none is given")
+	}
+
+	game = &types.Game{Name: "mygame", Seed: 42}
+	if err := s.Create(game); err != nil {
+		t.Fatal(err)
+	}
+	if game.Seed != 42 {
+		t.Errorf("unexpected seed. want=42, got %d", game.Seed)
+	}
+}
+
+func TestBuildBoard_SameSeed(t *testing.T) {
+	for _, seed := range []int64{1, 7, 1234567890} {
+		game1 := &types.Game{Rows: 16, Cols: 30, Mines: 99, Seed: seed}
+		game2 := &types.Game{Rows: 16, Cols: 30, Mines: 99, Seed: seed}
+		buildBoard(game1)
+		buildBoard(game2)
+
+		if !reflect.DeepEqual(game1.Grid, game2.Grid) {
+			t.Errorf("seed %d: boards with the same seed should be identical", seed)
+		}
+	}
+
+	game1 := &types.Game{Rows: 16, Cols: 30, Mines: 99, Seed: 1}
+	game2 := &types.Game{Rows: 16, Cols: 30, Mines: 99, Seed: 2}
+	buildBoard(game1)
+	buildBoard(game2)
+	if reflect.DeepEqual(game1.Grid, game2.Grid) {
+		t.Error("boards with different seeds should differ")
+	}
+}
+
+func TestStartGame_DeferredMines(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				return &types.Game{
+					Name:       name,
+					Cols:       3,
+					Rows:       3,
+					Mines:      8,
+					FirstClick: types.FirstClickSafe,
+					Status:     types.StatusNew,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game, err := s.Start("mygame", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Status != "started" {
+		t.Errorf("unexpected status. want='started', got %s", game.Status)
+	}
+	if !game.MinesPending {
+		t.Error("mines should be pending until the first click")
+	}
+	for _, row := range game.Grid {
+		for _, cell := range row {
+			if cell.Mine {
+				t.Fatal("no mine should be laid before the first click")
+			}
+		}
+	}
+}
+
+func TestClickCell_FirstClickSafe(t *testing.T) {
+	for _, mode := range []types.FirstClick{types.FirstClickSafe, types.FirstClickOpening} {
+		for n := 0; n < 20; n++ {
+			game := &types.Game{
+				Name:       "test",
+				Cols:       5,
+				Rows:       5,
+				Mines:      16,
+				Seed:       int64(n + 1),
+				FirstClick: mode,
+				Status:     "started",
+			}
+			newGrid(game)
+			game.MinesPending = true
+
+			s := GameService{
+				Store: &mocks.MockGameStore{
+					OnGetByName: func(name string) (*types.Game, error) {
+						return game, nil
+					},
+					OnUpdate: func(game *types.Game) error {
+						return nil
+					},
+				},
+			}
+
+			game, _, err := s.Click("test", 2, 2, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if game.Status == "over" {
+				t.Fatalf("%s: first click hit a mine", mode)
+			}
+			if game.MinesPending {
+				t.Fatalf("%s: mines should be laid after the first click", mode)
+			}
+			if mode == types.FirstClickOpening && game.Grid[2][2].Value != 0 {
+				t.Fatalf("%s: first click should open a region, got value %d", mode, game.Grid[2][2].Value)
+			}
+
+			mines := 0
+			for _, row := range game.Grid {
+				for _, cell := range row {
+					if cell.Mine {
+						mines++
+					}
+				}
+			}
+			if mines != 16 {
+				t.Fatalf("%s: unexpected mines. want=16, got %d", mode, mines)
+			}
+		}
+	}
+}
+
 func TestClickCell(t *testing.T) {
 	s := GameService{
 		Store: &mocks.MockGameStore{
@@ -170,7 +485,7 @@ func TestClickCell(t *testing.T) {
 		},
 	}
 
-	game, err := s.Click("test", 0, 0)
+	game, _, err := s.Click("test", 0, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -223,7 +538,7 @@ func TestClickCell_MineCell(t *testing.T) {
 		},
 	}
 
-	game, err := s.Click("test", 0, 1)
+	game, _, err := s.Click("test", 0, 1, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -277,7 +592,7 @@ func TestClickCell_Won(t *testing.T) {
 		},
 	}
 
-	game, err := s.Click("test", 1, 1)
+	game, _, err := s.Click("test", 1, 1, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -300,3 +615,927 @@ func TestClickCell_Won(t *testing.T) {
 		t.Errorf("unexpected grid. want=%v, got=%v", expected, game.Grid)
 	}
 }
+
+func TestClickCell_RevealsZeroRegion(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 0},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 0},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 0},
+						types.Cell{Mine: false, Clicked: false, Value: 0},
+						types.Cell{Mine: false, Clicked: false, Value: 0},
+					},
+				}
+				return &types.Game{
+					Name:   name,
+					Cols:   3,
+					Rows:   3,
+					Mines:  1,
+					Status: "started",
+					Grid:   grid,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game, revealed, err := s.Click("test", 2, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Status != "won" {
+		t.Errorf("unexpected status. want='won', got %s", game.Status)
+	}
+	if game.Clicks != 8 {
+		t.Errorf("unexpected clicks. want=8, got %d", game.Clicks)
+	}
+	if len(revealed) != 8 {
+		t.Errorf("unexpected revealed cells. want=8, got %d", len(revealed))
+	}
+	if revealed[0] != (types.Position{Row: 2, Col: 0}) {
+		t.Errorf("unexpected first revealed cell. want={2 0}, got %v", revealed[0])
+	}
+	if game.Grid[0][2].Clicked {
+		t.Error("mine cell should not be revealed")
+	}
+}
+
+func TestClickCell_StopsAtNumberedBorder(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 0},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 2},
+						types.Cell{Mine: true, Clicked: false, Value: 1},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 2},
+					},
+				}
+				return &types.Game{
+					Name:   name,
+					Cols:   3,
+					Rows:   3,
+					Mines:  2,
+					Status: "started",
+					Grid:   grid,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game, revealed, err := s.Click("test", 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Status != "started" {
+		t.Errorf("unexpected status. want='started', got %s", game.Status)
+	}
+
+	expected := []types.Position{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 1, Col: 0}, {Row: 1, Col: 1}}
+	if !reflect.DeepEqual(revealed, expected) {
+		t.Errorf("unexpected revealed cells. want=%v, got=%v", expected, revealed)
+	}
+	if game.Clicks != 4 {
+		t.Errorf("unexpected clicks. want=4, got %d", game.Clicks)
+	}
+}
+
+func TestFlagCell(t *testing.T) {
+	game := &types.Game{
+		Name:           "test",
+		Cols:           2,
+		Rows:           2,
+		Mines:          1,
+		RemainingMines: 1,
+		Status:         "started",
+		Grid: []types.CellGrid{
+			types.CellGrid{
+				types.Cell{Mine: false, Clicked: true, Value: 1},
+				types.Cell{Mine: true, Clicked: false, Value: 0},
+			},
+			types.CellGrid{
+				types.Cell{Mine: false, Clicked: false, Value: 1},
+				types.Cell{Mine: false, Clicked: false, Value: 1},
+			},
+		},
+	}
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				return game, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	expected := []struct {
+		mark      types.Mark
+		remaining int
+	}{
+		{types.MarkFlag, 0},
+		{types.MarkQuestion, 1},
+		{types.MarkNone, 1},
+	}
+	for _, e := range expected {
+		game, err := s.Flag("test", 0, 1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mark := game.Grid[0][1].Mark; mark != e.mark {
+			t.Errorf("unexpected mark. want=%q, got %q", e.mark, mark)
+		}
+		if game.RemainingMines != e.remaining {
+			t.Errorf("unexpected remaining mines. want=%d, got %d", e.remaining, game.RemainingMines)
+		}
+	}
+
+	if _, err := s.Flag("test", 0, 0, 0); err != ErrCellClicked {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrCellClicked, err)
+	}
+}
+
+func TestClickCell_Flagged(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0, Mark: types.MarkFlag},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+				}
+				return &types.Game{
+					Name:   name,
+					Cols:   2,
+					Rows:   2,
+					Mines:  1,
+					Status: "started",
+					Grid:   grid,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				t.Fatal("game should not be updated")
+				return nil
+			},
+		},
+	}
+
+	if _, _, err := s.Click("test", 0, 1, 0); err != ErrCellFlagged {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrCellFlagged, err)
+	}
+}
+
+func TestChordCell(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0, Mark: types.MarkFlag},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+				}
+				return &types.Game{
+					Name:   name,
+					Cols:   2,
+					Rows:   2,
+					Mines:  1,
+					Clicks: 1,
+					Status: "started",
+					Grid:   grid,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game, revealed, err := s.Chord("test", 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Status != "won" {
+		t.Errorf("unexpected status. want='won', got %s", game.Status)
+	}
+
+	expected := []types.Position{{Row: 1, Col: 0}, {Row: 1, Col: 1}}
+	if !reflect.DeepEqual(revealed, expected) {
+		t.Errorf("unexpected revealed cells. want=%v, got=%v", expected, revealed)
+	}
+}
+
+func TestChordCell_WrongFlag(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1, Mark: types.MarkFlag},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+				}
+				return &types.Game{
+					Name:   name,
+					Cols:   2,
+					Rows:   2,
+					Mines:  1,
+					Clicks: 1,
+					Status: "started",
+					Grid:   grid,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game, revealed, err := s.Chord("test", 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Status != "over" {
+		t.Errorf("unexpected status. want='over', got %s", game.Status)
+	}
+	if !game.Grid[0][1].Clicked {
+		t.Error("mine under the wrong flag should be revealed")
+	}
+	if len(revealed) != 2 {
+		t.Errorf("unexpected revealed cells. want=2, got %d", len(revealed))
+	}
+}
+
+func TestChordCell_Unsatisfied(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0},
+					},
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+				}
+				return &types.Game{
+					Name:   name,
+					Cols:   2,
+					Rows:   2,
+					Mines:  1,
+					Clicks: 1,
+					Status: "started",
+					Grid:   grid,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				t.Fatal("game should not be updated")
+				return nil
+			},
+		},
+	}
+
+	if _, _, err := s.Chord("test", 0, 0, 0); err != ErrFlagsMismatch {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrFlagsMismatch, err)
+	}
+}
+
+func TestHint(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				grid := []types.CellGrid{
+					types.CellGrid{
+						types.Cell{Mine: false, Clicked: true, Value: 1},
+						types.Cell{Mine: true, Clicked: false, Value: 0},
+						types.Cell{Mine: false, Clicked: false, Value: 1},
+					},
+				}
+				return &types.Game{
+					Name:   name,
+					Cols:   3,
+					Rows:   1,
+					Mines:  1,
+					Clicks: 1,
+					Hints:  1,
+					Status: "started",
+					Grid:   grid,
+				}, nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game, hint, err := s.Hint("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Hints != 2 {
+		t.Errorf("unexpected hints. want=2, got %d", game.Hints)
+	}
+	if !hint.Safe || hint.Position != (types.Position{Row: 0, Col: 2}) {
+		t.Errorf("unexpected hint. want=safe (0,2), got=%+v", hint)
+	}
+}
+
+func TestCreateGame_NoGuess(t *testing.T) {
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnInsert: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	game := &types.Game{Name: "mygame", NoGuess: true}
+	if err := s.Create(game); err != nil {
+		t.Fatal(err)
+	}
+	if game.FirstClick != types.FirstClickOpening {
+		t.Errorf("unexpected first click. want=%q, got %q", types.FirstClickOpening, game.FirstClick)
+	}
+
+	game = &types.Game{Name: "mygame", NoGuess: true, FirstClick: types.FirstClickOff}
+	if err := s.Create(game); err == nil {
+		t.Error("no guess games cannot lay mines on start")
+	}
+
+	game = &types.Game{Name: "mygame", GuessFree: true}
+	if err := s.Create(game); err != nil {
+		t.Fatal(err)
+	}
+	if game.GuessFree {
+		t.Error("only the server can certify a board guess free")
+	}
+}
+
+func TestClickCell_NoGuess(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		game := &types.Game{
+			Name:       "test",
+			Rows:       16,
+			Cols:       16,
+			Mines:      40,
+			Seed:       seed,
+			FirstClick: types.FirstClickOpening,
+			NoGuess:    true,
+			Status:     "started",
+		}
+		newGrid(game)
+		game.MinesPending = true
+
+		s := GameService{
+			Store: &mocks.MockGameStore{
+				OnGetByName: func(name string) (*types.Game, error) {
+					return game, nil
+				},
+				OnUpdate: func(game *types.Game) error {
+					return nil
+				},
+			},
+			NoGuessBudget: 5 * time.Second,
+		}
+
+		game, _, err := s.Click("test", 8, 8, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !game.GuessFree {
+			t.Fatalf("seed %d: board should be certified guess free", seed)
+		}
+		if !solvable(game, 8, 8) {
+			t.Fatalf("seed %d: board should be solvable without guessing", seed)
+		}
+	}
+}
+
+func TestGameService_InvalidState(t *testing.T) {
+	grid := []types.CellGrid{
+		types.CellGrid{
+			types.Cell{Mine: false, Clicked: true, Value: 1},
+			types.Cell{Mine: true, Clicked: true, Value: 0},
+		},
+	}
+	games := map[string]*types.Game{
+		"new":  &types.Game{Name: "new", Rows: 1, Cols: 2, Mines: 1, Status: types.StatusNew},
+		"over": &types.Game{Name: "over", Rows: 1, Cols: 2, Mines: 1, Status: types.StatusOver, Grid: grid},
+		"won":  &types.Game{Name: "won", Rows: 1, Cols: 2, Mines: 1, Status: types.StatusWon, Grid: grid},
+	}
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				return games[name], nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				t.Fatal("game should not be updated")
+				return nil
+			},
+		},
+	}
+
+	tests := []struct {
+		action string
+		game   string
+		call   func(name string) error
+	}{
+		{ActionClick, "new", func(name string) error { _, _, err := s.Click(name, 0, 0, 0); return err }},
+		{ActionChord, "new", func(name string) error { _, _, err := s.Chord(name, 0, 0, 0); return err }},
+		{ActionFlag, "new", func(name string) error { _, err := s.Flag(name, 0, 0, 0); return err }},
+		{ActionHint, "new", func(name string) error { _, _, err := s.Hint(name, 0); return err }},
+		{ActionProbabilities, "new", func(name string) error { _, err := s.Probabilities(name); return err }},
+		{ActionStart, "over", func(name string) error { _, err := s.Start(name, 0); return err }},
+		{ActionClick, "over", func(name string) error { _, _, err := s.Click(name, 0, 0, 0); return err }},
+		{ActionFlag, "won", func(name string) error { _, err := s.Flag(name, 0, 0, 0); return err }},
+	}
+	for _, tt := range tests {
+		err := tt.call(tt.game)
+		stateErr, ok := err.(*StateError)
+		if !ok {
+			t.Errorf("%s on %s game: unexpected error. want=*StateError, got=%v", tt.action, tt.game, err)
+			continue
+		}
+		if stateErr.Action != tt.action || stateErr.Status != games[tt.game].Status {
+			t.Errorf("%s on %s game: unexpected state error: %v", tt.action, tt.game, stateErr)
+		}
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	game := &types.Game{Status: types.StatusNew}
+	if err := setStatus(game, types.StatusWon); err == nil {
+		t.Error("a new game cannot be won")
+	}
+	if err := setStatus(game, types.StatusStarted); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(game, types.StatusWon); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(game, types.StatusStarted); err == nil {
+		t.Error("a won game cannot be restarted")
+	}
+	if !Finished(game.Status) {
+		t.Error("won games should be finished")
+	}
+}
+
+func TestSurrender(t *testing.T) {
+	games := map[string]*types.Game{
+		"new":     {Name: "new", Rows: 3, Cols: 3, Mines: 2, Seed: 1, Status: types.StatusNew},
+		"forged":  {Name: "forged", Rows: 3, Cols: 3, Mines: 2, Seed: 1, Status: types.StatusNew, Grid: []types.CellGrid{{{Mine: true}}}},
+		"pending": {Name: "pending", Rows: 3, Cols: 3, Mines: 2, Seed: 1, Status: types.StatusStarted, MinesPending: true},
+		"won":     {Name: "won", Rows: 3, Cols: 3, Mines: 2, Seed: 1, Status: types.StatusWon},
+	}
+	newGrid(games["pending"])
+
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				return games[name], nil
+			},
+			OnUpdate: func(game *types.Game) error {
+				return nil
+			},
+		},
+	}
+
+	for _, name := range []string{"new", "forged", "pending"} {
+		game, err := s.Surrender(name, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if game.Status != types.StatusSurrendered || game.MinesPending {
+			t.Errorf("%s: unexpected game. want surrendered with mines laid, got=%+v", name, game)
+		}
+
+		mines := 0
+		for i := range game.Grid {
+			for _, cell := range game.Grid[i] {
+				if cell.Mine {
+					mines++
+				}
+			}
+		}
+		if mines != game.Mines || len(game.Grid) != game.Rows {
+			t.Errorf("%s: unexpected mines on the board. want=%d, got=%d", name, game.Mines, mines)
+		}
+		if !Finished(game.Status) {
+			t.Errorf("%s: surrendered games should be finished", name)
+		}
+	}
+
+	if _, err := s.Surrender("won", 0); err == nil {
+		t.Error("a won game cannot be surrendered")
+	}
+}
+
+func TestDeleteGame(t *testing.T) {
+	deleted := ""
+	s := GameService{
+		Store: &mocks.MockGameStore{
+			OnGetByName: func(name string) (*types.Game, error) {
+				return &types.Game{Name: name, Status: types.StatusStarted, Version: 3}, nil
+			},
+			OnDelete: func(name string) error {
+				deleted = name
+				return nil
+			},
+		},
+	}
+
+	if err := s.Delete("test", 2); err != ErrVersionMismatch {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrVersionMismatch, err)
+	}
+	if deleted != "" {
+		t.Fatal("a stale delete should not remove the game")
+	}
+	if err := s.Delete("test", 3); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != "test" {
+		t.Errorf("unexpected game deleted. want=test, got=%q", deleted)
+	}
+}
+
+// mines returns the position of every mine of game.
+func mines(game *types.Game) []types.Position {
+	var positions []types.Position
+	for i := range game.Grid {
+		for j, cell := range game.Grid[i] {
+			if cell.Mine {
+				positions = append(positions, types.Position{Row: i, Col: j})
+			}
+		}
+	}
+	return positions
+}
+
+func TestRestartGame(t *testing.T) {
+	s := GameService{Store: memory.NewGameStore(memory.New())}
+	if err := s.Create(&types.Game{Name: "test", Rows: 5, Cols: 5, Mines: 5, Seed: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("test", 0); err != nil {
+		t.Fatal(err)
+	}
+	game, _, err := s.Click("test", 2, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := mines(game)
+	if _, _, err := s.Hint("test", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lose the game on purpose.
+	if _, _, err := s.Click("test", layout[0].Row, layout[0].Col, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	game, err = s.Restart("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Hints != 1 {
+		t.Errorf("restart should keep the hints taken. want=1, got=%d", game.Hints)
+	}
+	if game.Status != types.StatusStarted || game.Clicks != 0 || game.RemainingMines != game.Mines {
+		t.Errorf("unexpected game after restart: %+v", game)
+	}
+	for i := range game.Grid {
+		for j, cell := range game.Grid[i] {
+			if cell.Clicked || cell.Mark != types.MarkNone {
+				t.Errorf("cell (%d,%d) should be closed, got=%+v", i, j, cell)
+			}
+		}
+	}
+	if !reflect.DeepEqual(mines(game), layout) {
+		t.Errorf("restart should keep the mines. want=%v, got=%v", layout, mines(game))
+	}
+
+	if err := s.Create(&types.Game{Name: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Restart("new", 0); err == nil {
+		t.Error("a game not started cannot be restarted")
+	}
+
+	// A surrendered ranked game shows its mines, so it cannot be played
+	// again on them.
+	if err := s.Create(&types.Game{Name: "ranked", Ranked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("ranked", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Surrender("ranked", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Restart("ranked", 0); err != ErrRankedGame {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrRankedGame, err)
+	}
+}
+
+func TestForkGame(t *testing.T) {
+	s := GameService{Store: memory.NewGameStore(memory.New())}
+	if err := s.Create(&types.Game{Name: "test", Rows: 5, Cols: 5, Mines: 5, Seed: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("test", 0); err != nil {
+		t.Fatal(err)
+	}
+	parent, _, err := s.Click("test", 2, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fork, err := s.Fork("test", "practice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fork.Name != "practice" || fork.Parent != "test" || fork.Version != 1 {
+		t.Errorf("unexpected fork: %+v", fork)
+	}
+	if !reflect.DeepEqual(fork.Grid, parent.Grid) || fork.Clicks != parent.Clicks {
+		t.Error("the fork should have the board of its parent")
+	}
+
+	for _, pos := range mines(fork) {
+		if _, err := s.Flag("practice", pos.Row, pos.Col, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := s.Get("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored.Grid, parent.Grid) {
+		t.Error("playing the fork should not change its parent")
+	}
+
+	if _, err := s.Fork("test", "practice"); err != memory.ErrGameExists {
+		t.Errorf("unexpected error. want=%v, got=%v", memory.ErrGameExists, err)
+	}
+	if _, err := s.Fork("test", "bad name"); err == nil {
+		t.Error("forks should have a valid name")
+	}
+
+	if err := s.Create(&types.Game{Name: "ranked", Ranked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("ranked", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Fork("ranked", "peek"); err == nil {
+		t.Error("running ranked games cannot be forked")
+	} else if _, ok := err.(*StateError); !ok {
+		t.Errorf("unexpected error. want=*StateError, got=%v", err)
+	}
+	if _, err := s.Surrender("ranked", 0); err != nil {
+		t.Fatal(err)
+	}
+	review, err := s.Fork("ranked", "review")
+	if err != nil {
+		t.Fatalf("finished ranked games can be forked, got=%v", err)
+	}
+	if review.Ranked {
+		t.Error("the fork of a ranked game should be a practice game")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := GameService{
+		Store: memory.NewGameStore(memory.New()),
+		Now:   func() time.Time { return now },
+	}
+	if err := s.Create(&types.Game{Name: "test", Rows: 5, Cols: 5, Mines: 5, Seed: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("test", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo("test", 1, 0); err != ErrNoMoveToUndo {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrNoMoveToUndo, err)
+	}
+
+	opened, revealed, err := s.Click("test", 2, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := mines(opened)
+	now = now.Add(time.Second)
+	if _, err := s.Flag("test", layout[0].Row, layout[0].Col, 0); err != nil {
+		t.Fatal(err)
+	}
+	lost, _, err := s.Click("test", layout[1].Row, layout[1].Col, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lost.Status != types.StatusOver {
+		t.Fatalf("clicking a mine should lose the game, got=%s", lost.Status)
+	}
+
+	want := []types.Move{
+		{Kind: types.MoveReveal, Position: types.Position{Row: 2, Col: 2}, At: now.Add(-time.Second), Cells: revealed},
+		{Kind: types.MoveFlag, Position: layout[0], At: now, Cells: []types.Position{layout[0]}},
+		{Kind: types.MoveReveal, Position: layout[1], At: now, Cells: []types.Position{layout[1]}},
+	}
+	if !reflect.DeepEqual(lost.Moves, want) {
+		t.Errorf("unexpected moves.\nwant=%+v\ngot= %+v", want, lost.Moves)
+	}
+
+	game, err := s.Undo("test", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != types.StatusStarted || len(game.Moves) != 2 || game.Undos != 1 {
+		t.Errorf("unexpected game after undo: status=%s moves=%d undos=%d", game.Status, len(game.Moves), game.Undos)
+	}
+	if game.Grid[layout[1].Row][layout[1].Col].Clicked {
+		t.Error("the losing click should be taken back")
+	}
+	if game.Grid[layout[0].Row][layout[0].Col].Mark != types.MarkFlag || game.RemainingMines != game.Mines-1 {
+		t.Error("the flag should be kept")
+	}
+	if game.Clicks != opened.Clicks {
+		t.Errorf("unexpected clicks. want=%d, got=%d", opened.Clicks, game.Clicks)
+	}
+
+	if _, err := s.Undo("test", 3, 0); err == nil {
+		t.Error("only the moves made can be taken back")
+	}
+	game, err = s.Undo("test", 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(game.Moves) != 0 || game.Clicks != 0 || game.Undos != 2 || !reflect.DeepEqual(mines(game), layout) {
+		t.Errorf("undoing every move should close the board on the same mines, got=%+v", game)
+	}
+
+	if err := s.Create(&types.Game{Name: "ranked", Ranked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("ranked", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Click("ranked", 0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo("ranked", 1, 0); err != ErrRankedGame {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrRankedGame, err)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := GameService{
+		Store: memory.NewGameStore(memory.New()),
+		Now:   func() time.Time { return now },
+	}
+	if err := s.Create(&types.Game{Name: "test", Rows: 5, Cols: 5, Mines: 5, Seed: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("test", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The clock starts on the first reveal, not when the game starts.
+	now = now.Add(10 * time.Second)
+	game, _, err := s.Click("test", 2, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.StartedAt == nil || !game.StartedAt.Equal(now) {
+		t.Errorf("the clock should start on the first reveal, got=%v", game.StartedAt)
+	}
+	layout := mines(game)
+
+	now = now.Add(5 * time.Second)
+	if game, err = s.Pause("test", 0); err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != types.StatusPaused || View(game).Grid != nil {
+		t.Errorf("paused games should hide their board, got=%+v", View(game))
+	}
+	if _, _, err := s.Click("test", 0, 0, 0); err == nil {
+		t.Error("paused games cannot be played")
+	}
+
+	now = now.Add(time.Minute)
+	if game, err = s.Get("test"); err != nil {
+		t.Fatal(err)
+	}
+	if game.Elapsed != 5*time.Second {
+		t.Errorf("the clock should stop while paused. want=5s, got=%v", game.Elapsed)
+	}
+
+	if _, err := s.Resume("test", 0); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(3 * time.Second)
+	if game, _, err = s.Click("test", layout[0].Row, layout[0].Col, 0); err != nil {
+		t.Fatal(err)
+	}
+	if game.EndedAt == nil || game.Elapsed != 8*time.Second {
+		t.Errorf("the clock should stop when the game ends. want=8s, got=%v", game.Elapsed)
+	}
+
+	now = now.Add(time.Hour)
+	if game, err = s.Get("test"); err != nil {
+		t.Fatal(err)
+	}
+	if game.Elapsed != 8*time.Second {
+		t.Errorf("finished games should keep their time. want=8s, got=%v", game.Elapsed)
+	}
+	if _, err := s.Resume("test", 0); err == nil {
+		t.Error("games that are not paused cannot be resumed")
+	}
+}
+
+func TestCreateGame_ForgedClock(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := GameService{
+		Store: memory.NewGameStore(memory.New()),
+		Now:   func() time.Time { return now },
+	}
+
+	var game types.Game
+	body := `{"name":"test","rows":5,"cols":5,"mines":5,"seed":3,
+		"started_at":"2019-01-01T00:00:00Z","ended_at":"2019-01-01T00:00:01Z",
+		"paused_at":"2019-01-01T00:00:00Z","paused_for":3600000000000}`
+	if err := json.Unmarshal([]byte(body), &game); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(&game); err != nil {
+		t.Fatal(err)
+	}
+	if game.StartedAt != nil || game.EndedAt != nil || game.PausedAt != nil || game.PausedFor != 0 {
+		t.Fatalf("the clock of the request should be ignored, got=%+v", game)
+	}
+
+	if _, err := s.Start("test", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Click("test", 2, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Minute)
+	got, err := s.Get("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Elapsed != 30*time.Minute {
+		t.Errorf("unexpected elapsed time. want=30m, got=%v", got.Elapsed)
+	}
+}
@@ -0,0 +1,167 @@
+package minesweeper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+const defaultJanitorInterval = time.Minute
+
+// DefaultTTL is how long games can stay idle in each status before a Janitor
+// removes them. Running and paused games are kept the longest.
+var DefaultTTL = map[types.Status]time.Duration{
+	types.StatusNew:         time.Hour,
+	types.StatusStarted:     24 * time.Hour,
+	types.StatusPaused:      24 * time.Hour,
+	types.StatusOver:        time.Hour,
+	types.StatusWon:         time.Hour,
+	types.StatusSurrendered: time.Hour,
+	types.StatusTimeout:     time.Hour,
+}
+
+// Janitor periodically removes the games of Service that were left idle for
+// longer than the TTL of their status.
+type Janitor struct {
+	Service *GameService
+
+	// TTL is how long a game can stay idle in each status. Games in a status
+	// without a TTL never expire.
+	TTL map[types.Status]time.Duration
+
+	// Interval is the time between sweeps. Zero means defaultJanitorInterval.
+	Interval time.Duration
+
+	mu    sync.Mutex
+	stats JanitorStats
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// JanitorStats counts the work done by a Janitor.
+type JanitorStats struct {
+	Sweeps int64                  `json:"sweeps"`
+	Reaped map[types.Status]int64 `json:"reaped"`
+	Errors int64                  `json:"errors"`
+}
+
+// Start sweeps in the background until Stop is called.
+func (j *Janitor) Start() {
+	interval := j.Interval
+	if interval == 0 {
+		interval = defaultJanitorInterval
+	}
+
+	j.stop = make(chan struct{})
+	j.done = make(chan struct{})
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.Sweep()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background sweeps started by Start, waiting for a sweep in
+// progress to finish.
+func (j *Janitor) Stop() {
+	close(j.stop)
+	<-j.done
+}
+
+// Sweep removes every game idle for longer than the TTL of its status and
+// returns how many were removed.
+func (j *Janitor) Sweep() int {
+	now := j.Service.now()
+	reaped := make(map[types.Status]int64)
+	var errors int64
+
+	for status, ttl := range j.TTL {
+		if ttl <= 0 {
+			continue
+		}
+
+		query := types.GameQuery{
+			Status:        status,
+			UpdatedBefore: now.Add(-ttl),
+			Limit:         maxPageSize,
+		}
+		for {
+			page, err := j.Service.Store.List(query)
+			if err != nil {
+				errors++
+				break
+			}
+			for _, game := range page.Games {
+				ok, err := j.Service.expire(game.Name, status, query.UpdatedBefore)
+				switch {
+				case err != nil:
+					errors++
+				case ok:
+					reaped[status]++
+				}
+			}
+			if page.Next == "" {
+				break
+			}
+			query.Cursor = page.Next
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stats.Sweeps++
+	j.stats.Errors += errors
+	if j.stats.Reaped == nil {
+		j.stats.Reaped = make(map[types.Status]int64)
+	}
+	total := 0
+	for status, n := range reaped {
+		j.stats.Reaped[status] += n
+		total += int(n)
+	}
+	return total
+}
+
+// Stats returns the work done by the janitor so far.
+func (j *Janitor) Stats() JanitorStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	stats := j.stats
+	stats.Reaped = make(map[types.Status]int64, len(j.stats.Reaped))
+	for status, n := range j.stats.Reaped {
+		stats.Reaped[status] = n
+	}
+	return stats
+}
+
+// expire deletes the game called name if it is still in status and idle
+// since before cutoff, and reports whether it did.
+func (s *GameService) expire(name string, status types.Status, cutoff time.Time) (bool, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.Store.GetByName(name)
+	if err != nil {
+		return false, err
+	}
+	if game.Status != status || !game.UpdatedAt.Before(cutoff) {
+		return false, nil
+	}
+
+	if err := s.Store.Delete(name); err != nil {
+		return false, err
+	}
+	s.Timeouts.cancel(name)
+	return true, nil
+}
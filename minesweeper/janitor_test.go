@@ -0,0 +1,96 @@
+package minesweeper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/storage/memory"
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+func TestJanitor_Sweep(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &GameService{
+		Store: memory.NewGameStore(memory.New()),
+		Now:   func() time.Time { return now },
+	}
+	j := &Janitor{
+		Service: s,
+		TTL: map[types.Status]time.Duration{
+			types.StatusNew:     time.Hour,
+			types.StatusStarted: 3 * time.Hour,
+		},
+	}
+
+	for _, name := range []string{"idle", "started", "busy", "surrendered"} {
+		if err := s.Create(&types.Game{Name: name, FirstClick: types.FirstClickOff}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Start("started", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("busy", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Surrender("surrendered", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if reaped := j.Sweep(); reaped != 1 {
+		t.Errorf("unexpected games reaped. want=1, got=%d", reaped)
+	}
+	if _, err := s.Get("idle"); err != memory.ErrGameNotFound {
+		t.Errorf("idle new game should be reaped, got=%v", err)
+	}
+
+	if _, err := s.Flag("busy", 0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Hour)
+	if reaped := j.Sweep(); reaped != 1 {
+		t.Errorf("unexpected games reaped. want=1, got=%d", reaped)
+	}
+	for name, kept := range map[string]bool{"started": false, "busy": true, "surrendered": true} {
+		if _, err := s.Get(name); (err == nil) != kept {
+			t.Errorf("%s: unexpected result. want kept=%v, got err=%v", name, kept, err)
+		}
+	}
+
+	stats := j.Stats()
+	if stats.Sweeps != 2 || stats.Errors != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Reaped[types.StatusNew] != 1 || stats.Reaped[types.StatusStarted] != 1 {
+		t.Errorf("unexpected games reaped: %v", stats.Reaped)
+	}
+}
+
+func TestJanitor_StartStop(t *testing.T) {
+	s := &GameService{Store: memory.NewGameStore(memory.New())}
+	j := &Janitor{
+		Service:  s,
+		TTL:      map[types.Status]time.Duration{types.StatusNew: time.Nanosecond},
+		Interval: time.Millisecond,
+	}
+	if err := s.Create(&types.Game{Name: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	j.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for j.Stats().Reaped[types.StatusNew] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the janitor did not reap the game")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	j.Stop()
+
+	sweeps := j.Stats().Sweeps
+	time.Sleep(10 * time.Millisecond)
+	if j.Stats().Sweeps != sweeps {
+		t.Error("the janitor should not sweep once stopped")
+	}
+}
@@ -0,0 +1,43 @@
+package minesweeper
+
+import "sync"
+
+// gameLocks serializes the operations on each game. Locks are created on
+// demand and dropped once no operation holds or waits for them.
+type gameLocks struct {
+	mu    sync.Mutex
+	locks map[string]*gameLock
+}
+
+type gameLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until the game called name is free and returns the function
+// that releases it.
+func (l *gameLocks) lock(name string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*gameLock)
+	}
+	gl, ok := l.locks[name]
+	if !ok {
+		gl = &gameLock{}
+		l.locks[name] = gl
+	}
+	gl.refs++
+	l.mu.Unlock()
+
+	gl.Lock()
+	return func() {
+		gl.Unlock()
+
+		l.mu.Lock()
+		gl.refs--
+		if gl.refs == 0 {
+			delete(l.locks, name)
+		}
+		l.mu.Unlock()
+	}
+}
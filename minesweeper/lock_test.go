@@ -0,0 +1,119 @@
+package minesweeper
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/guilhermebr/minesweeper/storage/memory"
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// TestGameService_Concurrent hammers one game from many goroutines. Every
+// flag and every opened cell must be accounted for once the dust settles.
+func TestGameService_Concurrent(t *testing.T) {
+	const workers = 32
+
+	s := GameService{Store: memory.NewGameStore(memory.New())}
+	game := &types.Game{Name: "stress", Rows: 16, Cols: 16, Mines: 40, Seed: 7, FirstClick: types.FirstClickOff}
+	if err := s.Create(game); err != nil {
+		t.Fatal(err)
+	}
+	game, err := s.Start(game.Name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mines, safe []types.Position
+	for i := range game.Grid {
+		for j, cell := range game.Grid[i] {
+			if cell.Mine {
+				mines = append(mines, types.Position{Row: i, Col: j})
+			} else {
+				safe = append(safe, types.Position{Row: i, Col: j})
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := w; k < len(mines); k += workers {
+				if _, err := s.Flag(game.Name, mines[k].Row, mines[k].Col, 0); err != nil {
+					t.Errorf("flag %v: %v", mines[k], err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for _, k := range rng.Perm(len(safe)) {
+				s.Click(game.Name, safe[k].Row, safe[k].Col, 0)
+				s.Probabilities(game.Name)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	game, err = s.Store.GetByName(game.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != types.StatusWon {
+		t.Errorf("unexpected status. want=%s, got=%s", types.StatusWon, game.Status)
+	}
+	if game.Clicks != len(safe) {
+		t.Errorf("unexpected clicks. want=%d, got=%d", len(safe), game.Clicks)
+	}
+	if game.RemainingMines != 0 {
+		t.Errorf("unexpected remaining mines. want=0, got=%d", game.RemainingMines)
+	}
+}
+
+func TestGameService_Version(t *testing.T) {
+	s := GameService{Store: memory.NewGameStore(memory.New())}
+	game := &types.Game{Name: "versioned", Rows: 4, Cols: 4, Mines: 2, FirstClick: types.FirstClickOff}
+	if err := s.Create(game); err != nil {
+		t.Fatal(err)
+	}
+	if game.Version != 1 {
+		t.Fatalf("unexpected version. want=1, got=%d", game.Version)
+	}
+
+	stale, err := s.Store.GetByName(game.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game, err = s.Start(game.Name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Version != 2 {
+		t.Fatalf("unexpected version. want=2, got=%d", game.Version)
+	}
+
+	if _, err := s.Flag(game.Name, 0, 0, 1); err != ErrVersionMismatch {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrVersionMismatch, err)
+	}
+	if game, err = s.Flag(game.Name, 0, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if game.Version != 3 {
+		t.Errorf("unexpected version. want=3, got=%d", game.Version)
+	}
+	if _, err := s.Flag(game.Name, 0, 0, 0); err != nil {
+		t.Errorf("a zero version should match any: %v", err)
+	}
+
+	if err := s.Store.Update(stale); err != memory.ErrConflict {
+		t.Errorf("unexpected error. want=%v, got=%v", memory.ErrConflict, err)
+	}
+}
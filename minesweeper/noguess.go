@@ -0,0 +1,62 @@
+package minesweeper
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/solver"
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// placeMinesNoGuess lays the mines of a game whose first click is on i, j,
+// trying new layouts until the solver can clear the whole board from that
+// click without guessing. Layouts are drawn in order from the game seed. It
+// gives up when budget runs out, keeping the last layout, and reports
+// whether the board laid is guess free.
+func placeMinesNoGuess(game *types.Game, i, j int, budget time.Duration) bool {
+	rng := rand.New(rand.NewSource(game.Seed))
+	deadline := time.Now().Add(budget)
+	safe := safeCells(game, i, j)
+
+	for {
+		newGrid(game)
+		layMines(game, rng, safe)
+		if solvable(game, i, j) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+	}
+}
+
+// solvable plays a copy of game from a first click on i, j, opening only the
+// cells the solver proves safe, and reports whether that wins the game.
+func solvable(game *types.Game, i, j int) bool {
+	play := *game
+	play.Clicks = 0
+	play.Status = types.StatusStarted
+	play.Grid = make([]types.CellGrid, len(game.Grid))
+	for row := range game.Grid {
+		play.Grid[row] = make(types.CellGrid, len(game.Grid[row]))
+		for col, cell := range game.Grid[row] {
+			play.Grid[row][col] = types.Cell{Mine: cell.Mine, Value: cell.Value}
+		}
+	}
+
+	if _, err := clickCell(&play, i, j); err != nil {
+		return false
+	}
+	for !Finished(play.Status) {
+		result := solver.Solve(&play)
+		if len(result.Safe) == 0 {
+			return false
+		}
+		for _, d := range result.Safe {
+			if !play.Grid[d.Row][d.Col].Clicked {
+				clickCell(&play, d.Row, d.Col)
+			}
+		}
+	}
+	return play.Status == types.StatusWon
+}
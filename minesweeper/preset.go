@@ -0,0 +1,84 @@
+package minesweeper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// Preset is a board size and number of mines games can be created with by
+// naming it as their difficulty.
+type Preset struct {
+	Rows  int `json:"rows"`
+	Cols  int `json:"cols"`
+	Mines int `json:"mines"`
+}
+
+// DefaultPresets are the classic difficulties.
+var DefaultPresets = map[string]Preset{
+	"beginner":     {Rows: 9, Cols: 9, Mines: 10},
+	"intermediate": {Rows: 16, Cols: 16, Mines: 40},
+	"expert":       {Rows: 16, Cols: 30, Mines: 99},
+}
+
+// preset returns the preset called name, preferring the presets of the
+// operator to the default ones.
+func (s *GameService) preset(name string) (Preset, bool) {
+	if preset, ok := s.Presets[name]; ok {
+		return preset, true
+	}
+	preset, ok := DefaultPresets[name]
+	return preset, ok
+}
+
+// presetNames returns the quoted names of every preset, sorted.
+func (s *GameService) presetNames() string {
+	var names []string
+	for name := range DefaultPresets {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	for name := range s.Presets {
+		if _, ok := DefaultPresets[name]; !ok {
+			names = append(names, fmt.Sprintf("%q", name))
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ValidatePresets checks that every game created from presets would be
+// valid, so that operators learn about a bad preset when they load it rather
+// than from the players using it. Fields are reported as the preset name
+// followed by the field, as in "huge.rows".
+func ValidatePresets(presets map[string]Preset) error {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	v := &ValidationError{}
+	for _, name := range names {
+		if !validName.MatchString(name) {
+			v.add(name, nameConstraint)
+			continue
+		}
+
+		preset := presets[name]
+		game := &types.Game{
+			Name:       name,
+			Rows:       preset.Rows,
+			Cols:       preset.Cols,
+			Mines:      preset.Mines,
+			FirstClick: types.FirstClickSafe,
+		}
+		if err, ok := validateGame(game).(*ValidationError); ok {
+			for _, f := range err.Fields {
+				v.add(name+"."+f.Field, "%s", f.Constraint)
+			}
+		}
+	}
+	return v.err()
+}
@@ -0,0 +1,130 @@
+package minesweeper
+
+import (
+	"fmt"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// Export returns the replay of game.
+func Export(game *types.Game) *types.Replay {
+	replay := &types.Replay{
+		Name:       game.Name,
+		Owner:      game.Owner,
+		Parent:     game.Parent,
+		Difficulty: game.Difficulty,
+		CreatedAt:  game.CreatedAt,
+		UpdatedAt:  game.UpdatedAt,
+		Rows:       game.Rows,
+		Cols:       game.Cols,
+		Mines:      game.Mines,
+		Seed:       game.Seed,
+		FirstClick: game.FirstClick,
+		NoGuess:    game.NoGuess,
+		GuessFree:  game.GuessFree,
+		Ranked:     game.Ranked,
+		TimeLimit:  game.TimeLimit,
+		Moves:      make([]types.Move, 0, len(game.Moves)),
+		Status:     game.Status,
+		PausedFor:  game.PausedFor,
+		Hints:      game.Hints,
+		Undos:      game.Undos,
+		Version:    game.Version,
+	}
+	clone := game.Clone()
+	replay.Moves = append(replay.Moves, clone.Moves...)
+	replay.StartedAt = clone.StartedAt
+	replay.EndedAt = clone.EndedAt
+	if !game.MinesPending {
+		for i := range game.Grid {
+			for j, cell := range game.Grid[i] {
+				if cell.Mine {
+					replay.MinePositions = append(replay.MinePositions, types.Position{Row: i, Col: j})
+				}
+			}
+		}
+	}
+	return replay
+}
+
+// Replay plays replay again and returns the game exactly as it was when the
+// replay was exported.
+func Replay(replay *types.Replay) (*types.Game, error) {
+	game, err := ReplayTo(replay, len(replay.Moves))
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Status != replay.Status {
+		if err := setStatus(game, replay.Status); err != nil {
+			return nil, fmt.Errorf("replay ends with status %q, want %q", game.Status, replay.Status)
+		}
+	}
+	game.UpdatedAt = replay.UpdatedAt
+	game.StartedAt = replay.StartedAt
+	game.EndedAt = replay.EndedAt
+	game.PausedFor = replay.PausedFor
+	game.Elapsed = Elapsed(game, game.UpdatedAt)
+	game.GuessFree = replay.GuessFree
+	game.Hints = replay.Hints
+	game.Undos = replay.Undos
+	game.Version = replay.Version
+	return game, nil
+}
+
+// ReplayTo plays the first step moves of replay and returns the game as it
+// was right after them, its clock included. Step zero is the board before
+// the first move. Pauses are not recorded, so the time played may be longer
+// than it was.
+func ReplayTo(replay *types.Replay, step int) (*types.Game, error) {
+	if err := validateReplay(replay, step); err != nil {
+		return nil, err
+	}
+
+	game := &types.Game{
+		Name:           replay.Name,
+		Owner:          replay.Owner,
+		Parent:         replay.Parent,
+		Difficulty:     replay.Difficulty,
+		CreatedAt:      replay.CreatedAt,
+		UpdatedAt:      replay.CreatedAt,
+		Rows:           replay.Rows,
+		Cols:           replay.Cols,
+		Mines:          replay.Mines,
+		RemainingMines: replay.Mines,
+		Status:         types.StatusStarted,
+		Seed:           replay.Seed,
+		FirstClick:     replay.FirstClick,
+		NoGuess:        replay.NoGuess,
+		Ranked:         replay.Ranked,
+		TimeLimit:      replay.TimeLimit,
+	}
+
+	newGrid(game)
+	if len(replay.MinePositions) == 0 {
+		game.MinesPending = true
+	}
+	for _, pos := range replay.MinePositions {
+		game.Grid[pos.Row][pos.Col].Mine = true
+		setAdjacentValues(game, pos.Row, pos.Col)
+	}
+
+	for k, move := range replay.Moves[:step] {
+		if err := validateCell(game, move.Row, move.Col); err != nil {
+			return nil, fmt.Errorf("move %d: %v", k+1, err)
+		}
+		if game.MinesPending && move.Kind != types.MoveFlag {
+			return nil, fmt.Errorf("move %d: the replay has no mines", k+1)
+		}
+		if err := playMove(game, move); err != nil {
+			return nil, fmt.Errorf("move %d: %v", k+1, err)
+		}
+		tick(game, move)
+		game.Moves = append(game.Moves, move)
+	}
+	if step > 0 {
+		game.UpdatedAt = replay.Moves[step-1].At
+	}
+	game.Elapsed = Elapsed(game, game.UpdatedAt)
+	return game, nil
+}
@@ -0,0 +1,164 @@
+package minesweeper
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/solver"
+	"github.com/guilhermebr/minesweeper/storage/memory"
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// roundTrip sends replay through JSON, as clients get it.
+func roundTrip(t *testing.T, replay *types.Replay) *types.Replay {
+	data, err := json.Marshal(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded types.Replay
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return &decoded
+}
+
+func TestReplay(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := GameService{
+		Store: memory.NewGameStore(memory.New()),
+		Now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	}
+
+	games := []*types.Game{
+		{Name: "safe", Rows: 8, Cols: 8, Mines: 10, Seed: 5},
+		{Name: "noguess", Rows: 8, Cols: 8, Mines: 10, Seed: 6, NoGuess: true},
+		{Name: "off", Rows: 8, Cols: 8, Mines: 10, Seed: 7, FirstClick: types.FirstClickOff},
+		{Name: "beginner", Difficulty: "beginner", Seed: 8},
+	}
+	for _, game := range games {
+		if err := s.Create(game); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Start(game.Name, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		// Flag a cell, then play the safe moves the solver finds, taking
+		// one back on the way, until nothing is left to prove.
+		if _, err := s.Flag(game.Name, 0, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Flag(game.Name, 0, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		played, _, err := s.Click(game.Name, 4, 4, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots := []*types.Game{nil, nil, nil, played}
+		undone := false
+		for !Finished(played.Status) {
+			result := solver.Solve(played)
+			if len(result.Safe) == 0 {
+				break
+			}
+			pos := result.Safe[0].Position
+			if played, _, err = s.Click(game.Name, pos.Row, pos.Col, 0); err != nil {
+				t.Fatal(err)
+			}
+			snapshots = append(snapshots, played)
+			if len(snapshots) == 6 && !undone {
+				if played, err = s.Undo(game.Name, 1, 0); err != nil {
+					t.Fatal(err)
+				}
+				snapshots = snapshots[:5]
+				undone = true
+			}
+		}
+		if !Finished(played.Status) {
+			if played, err = s.Surrender(game.Name, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		replay, err := s.Replay(game.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replay = roundTrip(t, replay)
+
+		replayed, err := Replay(replay)
+		if err != nil {
+			t.Fatalf("%s: %v", game.Name, err)
+		}
+		stored, err := s.Get(game.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(replayed, stored) {
+			t.Errorf("%s: replay should reproduce the game.\nwant=%+v\ngot= %+v", game.Name, stored, replayed)
+		}
+
+		for k := 3; k < len(snapshots); k++ {
+			step, err := ReplayTo(replay, k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(step.Grid, snapshots[k].Grid) || step.Status != snapshots[k].Status {
+				t.Errorf("%s: unexpected board after move %d", game.Name, k)
+			}
+		}
+		if _, err := ReplayTo(replay, len(replay.Moves)+1); err == nil {
+			t.Errorf("%s: steps past the last move should fail", game.Name)
+		}
+	}
+}
+
+func TestReplay_Running(t *testing.T) {
+	s := GameService{Store: memory.NewGameStore(memory.New())}
+	if err := s.Create(&types.Game{Name: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("test", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Replay("test"); err == nil {
+		t.Error("running games cannot be replayed, it would show their mines")
+	}
+}
+
+func TestReplay_Invalid(t *testing.T) {
+	tests := []struct {
+		replay types.Replay
+		fields []string
+	}{
+		{types.Replay{Rows: -1, Cols: 5, Mines: 1}, []string{"rows"}},
+		{types.Replay{Rows: 5, Cols: 1 << 20, Mines: 1}, []string{"cols"}},
+		{types.Replay{Rows: 2, Cols: 2, Mines: 4}, []string{"mines"}},
+		{types.Replay{Rows: 2, Cols: 2, Mines: 2, MinePositions: []types.Position{{Row: 0, Col: 0}, {Row: 0, Col: 0}}}, []string{"mine_positions"}},
+		{types.Replay{Rows: 2, Cols: 2, Mines: 1, MinePositions: []types.Position{{Row: 2, Col: 0}}}, []string{"mine_positions"}},
+		{types.Replay{Rows: 2, Cols: 2, Mines: 2, MinePositions: []types.Position{{Row: 0, Col: 0}}}, []string{"mine_positions"}},
+	}
+	for _, tt := range tests {
+		_, err := Replay(&tt.replay)
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("%+v: unexpected error. want=*ValidationError, got=%v", tt.replay, err)
+			continue
+		}
+
+		var fields []string
+		for _, f := range verr.Fields {
+			fields = append(fields, f.Field)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%+v: unexpected fields. want=%v, got=%v", tt.replay, tt.fields, fields)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package minesweeper
+
+import (
+	"fmt"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// Actions a player can take on a game.
+const (
+	ActionStart         = "start"
+	ActionClick         = "click"
+	ActionChord         = "chord"
+	ActionFlag          = "flag"
+	ActionHint          = "hint"
+	ActionProbabilities = "probabilities"
+	ActionSurrender     = "surrender"
+	ActionRestart       = "restart"
+	ActionUndo          = "undo"
+	ActionReplay        = "replay"
+	ActionPause         = "pause"
+	ActionResume        = "resume"
+	ActionFork          = "fork"
+)
+
+// transitions lists, for every status, the statuses a game can move to.
+// Statuses without transitions are final.
+var transitions = map[types.Status][]types.Status{
+	types.StatusNew:         {types.StatusStarted, types.StatusSurrendered},
+	types.StatusStarted:     {types.StatusOver, types.StatusWon, types.StatusSurrendered, types.StatusPaused, types.StatusTimeout},
+	types.StatusPaused:      {types.StatusStarted, types.StatusSurrendered},
+	types.StatusOver:        nil,
+	types.StatusWon:         nil,
+	types.StatusSurrendered: nil,
+	types.StatusTimeout:     nil,
+}
+
+// actions lists the statuses each action can be taken on.
+var actions = map[string][]types.Status{
+	ActionStart:         {types.StatusNew},
+	ActionClick:         {types.StatusStarted},
+	ActionChord:         {types.StatusStarted},
+	ActionFlag:          {types.StatusStarted},
+	ActionHint:          {types.StatusStarted},
+	ActionProbabilities: {types.StatusStarted, types.StatusOver, types.StatusWon, types.StatusSurrendered, types.StatusTimeout},
+	ActionSurrender:     {types.StatusNew, types.StatusStarted, types.StatusPaused},
+	ActionRestart:       {types.StatusStarted, types.StatusOver, types.StatusWon, types.StatusSurrendered, types.StatusTimeout},
+	ActionUndo:          {types.StatusStarted, types.StatusOver, types.StatusWon},
+	ActionReplay:        {types.StatusOver, types.StatusWon, types.StatusSurrendered, types.StatusTimeout},
+	ActionPause:         {types.StatusStarted},
+	ActionResume:        {types.StatusPaused},
+}
+
+// StateError is returned when an action is not allowed on a game in its
+// current status, or a game cannot move to the requested status.
+type StateError struct {
+	Action string
+	Status types.Status
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("cannot %s a game with status %q", e.Action, e.Status)
+}
+
+// Finished reports whether status is final.
+func Finished(status types.Status) bool {
+	_, known := transitions[status]
+	return known && len(transitions[status]) == 0
+}
+
+// checkAction returns a *StateError when action is not allowed on game.
+func checkAction(game *types.Game, action string) error {
+	for _, status := range actions[action] {
+		if game.Status == status {
+			return nil
+		}
+	}
+	return &StateError{Action: action, Status: game.Status}
+}
+
+// setStatus moves game to status, returning a *StateError when the
+// transitions table does not allow it.
+func setStatus(game *types.Game, status types.Status) error {
+	for _, next := range transitions[game.Status] {
+		if next == status {
+			game.Status = status
+			return nil
+		}
+	}
+	return &StateError{Action: fmt.Sprintf("move to %q", status), Status: game.Status}
+}
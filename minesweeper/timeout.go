@@ -0,0 +1,290 @@
+package minesweeper
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// Timeouts ends the games of Service as soon as their time limit runs out,
+// even when nobody plays them. Deadlines wait in a single queue served by one
+// goroutine, so each running game only costs an entry in the queue.
+type Timeouts struct {
+	Service *GameService
+
+	mu     sync.Mutex
+	queue  deadlineQueue
+	byName map[string]*deadline
+	stats  TimeoutStats
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// TimeoutStats counts the work done by Timeouts.
+type TimeoutStats struct {
+	Pending int   `json:"pending"`
+	Expired int64 `json:"expired"`
+	Errors  int64 `json:"errors"`
+}
+
+type deadline struct {
+	name  string
+	at    time.Time
+	index int
+}
+
+// deadlineQueue is a heap of deadlines, the earliest first.
+type deadlineQueue []*deadline
+
+func (q deadlineQueue) Len() int           { return len(q) }
+func (q deadlineQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+
+func (q deadlineQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *deadlineQueue) Push(x interface{}) {
+	d := x.(*deadline)
+	d.index = len(*q)
+	*q = append(*q, d)
+}
+
+func (q *deadlineQueue) Pop() interface{} {
+	old := *q
+	d := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return d
+}
+
+// Deadline returns when the time limit of game runs out, and false when its
+// clock is not running or it has no limit.
+func Deadline(game *types.Game) (time.Time, bool) {
+	if game.TimeLimit <= 0 || game.Status != types.StatusStarted ||
+		game.StartedAt == nil || game.PausedAt != nil || game.EndedAt != nil {
+		return time.Time{}, false
+	}
+	limit := time.Duration(game.TimeLimit) * time.Millisecond
+	return game.StartedAt.Add(game.PausedFor + limit), true
+}
+
+// Start picks up the games of Service already running against a time limit
+// and ends them as their time runs out, until Stop is called.
+func (t *Timeouts) Start() {
+	t.mu.Lock()
+	t.wake = make(chan struct{}, 1)
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+	t.mu.Unlock()
+
+	query := types.GameQuery{Status: types.StatusStarted, Limit: maxPageSize}
+	for {
+		page, err := t.Service.Store.List(query)
+		if err != nil {
+			t.mu.Lock()
+			t.stats.Errors++
+			t.mu.Unlock()
+			break
+		}
+		for _, game := range page.Games {
+			t.track(game)
+		}
+		if page.Next == "" {
+			break
+		}
+		query.Cursor = page.Next
+	}
+
+	go t.run()
+}
+
+// Stop ends the work started by Start, waiting for games being timed out to
+// be stored.
+func (t *Timeouts) Stop() {
+	close(t.stop)
+	<-t.done
+}
+
+// Stats returns the work done so far.
+func (t *Timeouts) Stats() TimeoutStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	stats.Pending = len(t.queue)
+	return stats
+}
+
+func (t *Timeouts) run() {
+	defer close(t.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-t.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-t.stop:
+			return
+		}
+
+		t.expire()
+		if wait, ok := t.next(); ok {
+			timer.Reset(wait)
+		}
+	}
+}
+
+// next returns the time left until the earliest deadline.
+func (t *Timeouts) next() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.queue) == 0 {
+		return 0, false
+	}
+	return t.queue[0].at.Sub(t.Service.now()), true
+}
+
+// expire times out every game whose deadline passed.
+func (t *Timeouts) expire() {
+	now := t.Service.now()
+
+	var due []string
+	t.mu.Lock()
+	for len(t.queue) > 0 && !t.queue[0].at.After(now) {
+		d := heap.Pop(&t.queue).(*deadline)
+		delete(t.byName, d.name)
+		due = append(due, d.name)
+	}
+	t.mu.Unlock()
+
+	var expired, errors int64
+	for _, name := range due {
+		ok, err := t.Service.timeout(name)
+		switch {
+		case err != nil:
+			errors++
+		case ok:
+			expired++
+		}
+	}
+
+	t.mu.Lock()
+	t.stats.Expired += expired
+	t.stats.Errors += errors
+	t.mu.Unlock()
+}
+
+// track keeps the deadline of game in the queue, or drops it when its clock
+// is not running against a time limit.
+func (t *Timeouts) track(game *types.Game) {
+	if t == nil {
+		return
+	}
+
+	at, ok := Deadline(game)
+	if !ok {
+		t.cancel(game.Name)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.byName == nil {
+		t.byName = make(map[string]*deadline)
+	}
+	if d, found := t.byName[game.Name]; found {
+		d.at = at
+		heap.Fix(&t.queue, d.index)
+	} else {
+		d = &deadline{name: game.Name, at: at}
+		heap.Push(&t.queue, d)
+		t.byName[game.Name] = d
+	}
+
+	// Wake the queue up when the earliest deadline changed.
+	if t.queue[0].name == game.Name {
+		select {
+		case t.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// cancel forgets the deadline of the game called name.
+func (t *Timeouts) cancel(name string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if d, found := t.byName[name]; found {
+		heap.Remove(&t.queue, d.index)
+		delete(t.byName, name)
+	}
+}
+
+// timeout ends the game called name if its time ran out, and reports
+// whether it did.
+func (s *GameService) timeout(name string) (bool, error) {
+	defer s.locks.lock(name)()
+
+	game, err := s.Store.GetByName(name)
+	if err != nil {
+		return false, err
+	}
+
+	expired, err := s.checkTime(game)
+	if err != nil {
+		return false, err
+	}
+	if !expired {
+		// The deadline moved, keep waiting for the new one.
+		s.Timeouts.track(game)
+	}
+	return expired, nil
+}
+
+// checkTime ends game, stored, when its time limit ran out, and reports
+// whether it did.
+func (s *GameService) checkTime(game *types.Game) (bool, error) {
+	at, ok := Deadline(game)
+	if !ok || s.now().Before(at) {
+		return false, nil
+	}
+
+	if err := setStatus(game, types.StatusTimeout); err != nil {
+		return false, err
+	}
+	stopClock(game, at)
+	return true, s.update(game)
+}
+
+// load returns the game called name, ending it first when its time limit ran
+// out. Callers hold the lock of the game.
+func (s *GameService) load(name string) (*types.Game, error) {
+	game, err := s.Store.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.checkTime(game); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
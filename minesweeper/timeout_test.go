@@ -0,0 +1,158 @@
+package minesweeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/storage/memory"
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+func TestTimeouts(t *testing.T) {
+	s := &GameService{Store: memory.NewGameStore(memory.New())}
+	timeouts := &Timeouts{Service: s}
+	s.Timeouts = timeouts
+
+	play := func(name string, limit int64) {
+		if err := s.Create(&types.Game{Name: name, Rows: 9, Cols: 9, Mines: 20, Seed: limit, TimeLimit: limit}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Start(name, 0); err != nil {
+			t.Fatal(err)
+		}
+		game, _, err := s.Click(name, 4, 4, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.Status != types.StatusStarted {
+			t.Fatalf("%s: the first click should not end the game, got=%s", name, game.Status)
+		}
+	}
+
+	// Games already running are picked up on start, the others as they are
+	// played.
+	const games = 200
+	for n := 0; n < games/2; n++ {
+		play(fmt.Sprintf("before-%d", n), int64(20+n))
+	}
+	timeouts.Start()
+	defer timeouts.Stop()
+	for n := 0; n < games/2; n++ {
+		play(fmt.Sprintf("after-%d", n), int64(20+n))
+	}
+
+	play("paused", 60000)
+	if _, err := s.Pause("paused", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for timeouts.Stats().Expired < games && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats := timeouts.Stats()
+	if stats.Expired != games || stats.Pending != 0 || stats.Errors != 0 {
+		t.Fatalf("unexpected stats. want %d expired, got=%+v", games, stats)
+	}
+	for n := 0; n < games/2; n++ {
+		name := fmt.Sprintf("after-%d", n)
+		game, err := s.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.Status != types.StatusTimeout || game.Elapsed != time.Duration(game.TimeLimit)*time.Millisecond {
+			t.Errorf("%s: unexpected game after its time ran out: status=%s, elapsed=%v", name, game.Status, game.Elapsed)
+		}
+	}
+
+	game, err := s.Get("paused")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != types.StatusPaused {
+		t.Errorf("paused games should not run out of time, got=%s", game.Status)
+	}
+}
+
+func TestGameService_TimeLimit(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := GameService{
+		Store: memory.NewGameStore(memory.New()),
+		Now:   func() time.Time { return now },
+	}
+	if err := s.Create(&types.Game{Name: "test", Rows: 9, Cols: 9, Mines: 20, Seed: 1, TimeLimit: 60000}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("test", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Click("test", 4, 4, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(45 * time.Second)
+	game, err := s.Get("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left := View(game).Remaining; left == nil || *left != 15000 {
+		t.Errorf("unexpected remaining time. want=15000, got=%v", left)
+	}
+
+	// Without Timeouts the game ends when it is next played.
+	now = now.Add(time.Minute)
+	if _, err := s.Flag("test", 0, 0, 0); err == nil {
+		t.Error("games out of time cannot be played")
+	}
+	if game, err = s.Get("test"); err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != types.StatusTimeout || *View(game).Remaining != 0 {
+		t.Errorf("unexpected game after its time ran out: %+v", View(game))
+	}
+
+	if err := s.Create(&types.Game{Name: "negative", TimeLimit: -1}); err == nil {
+		t.Error("negative time limits should be rejected")
+	}
+}
+
+func TestTimeouts_ForgedClock(t *testing.T) {
+	s := &GameService{Store: memory.NewGameStore(memory.New())}
+	timeouts := &Timeouts{Service: s}
+	s.Timeouts = timeouts
+	timeouts.Start()
+	defer timeouts.Stop()
+
+	// A client cannot buy itself more time with the clock fields.
+	var game types.Game
+	body := `{"name":"forged","rows":9,"cols":9,"mines":20,"seed":1,"time_limit_ms":50,
+		"started_at":"2999-01-01T00:00:00Z","paused_for":3600000000000}`
+	if err := json.Unmarshal([]byte(body), &game); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(&game); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start("forged", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Click("forged", 4, 4, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for timeouts.Stats().Expired < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	got, err := s.Get("forged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != types.StatusTimeout || got.Elapsed != 50*time.Millisecond {
+		t.Errorf("unexpected game after its time ran out: status=%s, elapsed=%v", got.Status, got.Elapsed)
+	}
+}
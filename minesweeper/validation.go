@@ -0,0 +1,220 @@
+package minesweeper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// validName matches names that can be used as a path segment of the API.
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+const nameConstraint = "must have 1 to 64 letters, digits, '-' or '_'"
+
+// FieldError describes a request field that failed validation and the
+// constraint it broke.
+type FieldError struct {
+	Field      string `json:"field"`
+	Constraint string `json:"constraint"`
+}
+
+// ValidationError is returned when one or more fields of a request are
+// invalid.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = fmt.Sprintf("%s %s", f.Field, f.Constraint)
+	}
+	return "invalid request: " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) add(field, constraint string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Constraint: fmt.Sprintf(constraint, args...)})
+}
+
+// err returns e when any field failed validation, nil otherwise.
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// validateGame checks the parameters of a game being created.
+func validateGame(game *types.Game) error {
+	v := &ValidationError{}
+
+	if !validName.MatchString(game.Name) {
+		v.add("name", nameConstraint)
+	}
+	if game.Owner != "" && !validName.MatchString(game.Owner) {
+		v.add("owner", nameConstraint)
+	}
+
+	validSize := true
+	if game.Rows < 1 || game.Rows > maxRows {
+		v.add("rows", "must be between 1 and %d", maxRows)
+		validSize = false
+	}
+	if game.Cols < 1 || game.Cols > maxCols {
+		v.add("cols", "must be between 1 and %d", maxCols)
+		validSize = false
+	}
+	if validSize {
+		validateMines(v, game)
+	}
+
+	switch game.FirstClick {
+	case types.FirstClickSafe, types.FirstClickOpening, types.FirstClickOff:
+	default:
+		v.add("first_click", "must be one of %q, %q or %q", types.FirstClickSafe, types.FirstClickOpening, types.FirstClickOff)
+	}
+	if game.NoGuess && game.FirstClick == types.FirstClickOff {
+		v.add("no_guess", "needs first_click other than %q", types.FirstClickOff)
+	}
+	if game.TimeLimit < 0 {
+		v.add("time_limit_ms", "must not be negative")
+	}
+	// A known seed gives the mines away before the first click.
+	if game.Ranked && game.Seed != 0 {
+		v.add("seed", "must not be set on ranked games")
+	}
+
+	return v.err()
+}
+
+// validateMines checks that the mines of game leave room to play: the first
+// click must be kept free of mines, and boards too crowded are mostly
+// guesswork.
+func validateMines(v *ValidationError, game *types.Game) {
+	cells := game.Rows * game.Cols
+
+	// Opening first clicks keep a whole 3x3 square free, or as much of it
+	// as the board has.
+	opening := 0
+	if game.FirstClick == types.FirstClickOpening {
+		rows, cols := game.Rows, game.Cols
+		if rows > 3 {
+			rows = 3
+		}
+		if cols > 3 {
+			cols = 3
+		}
+		opening = rows * cols
+	}
+	dense := int(float64(cells) * maxDensity)
+
+	switch {
+	case game.Mines < 1:
+		v.add("mines", "must be at least 1")
+	case game.Mines > cells-1:
+		v.add("mines", "must be at most %d to leave a cell free of mines", cells-1)
+	case opening > 0 && game.Mines > cells-opening:
+		v.add("mines", "must be at most %d to keep the %d cells of an opening first click free of mines", cells-opening, opening)
+	case game.Mines > dense:
+		v.add("mines", "must be at most %d, %.0f%% of the %d cells, as denser boards cannot be played without guessing", dense, maxDensity*100, cells)
+	}
+}
+
+// validateFork checks the name given to the copy of a game.
+func validateFork(newName string) error {
+	v := &ValidationError{}
+	if !validName.MatchString(newName) {
+		v.add("name", nameConstraint)
+	}
+	return v.err()
+}
+
+// validateUndo checks the number of moves to take back on game.
+func validateUndo(game *types.Game, moves int) error {
+	v := &ValidationError{}
+	if moves < 1 || moves > len(game.Moves) {
+		v.add("moves", "must be between 1 and %d", len(game.Moves))
+	}
+	return v.err()
+}
+
+// validateReplay checks that replay describes a board that can be built, and
+// that step is one of its moves.
+func validateReplay(replay *types.Replay, step int) error {
+	v := &ValidationError{}
+
+	validSize := true
+	if replay.Rows < 1 || replay.Rows > maxRows {
+		v.add("rows", "must be between 1 and %d", maxRows)
+		validSize = false
+	}
+	if replay.Cols < 1 || replay.Cols > maxCols {
+		v.add("cols", "must be between 1 and %d", maxCols)
+		validSize = false
+	}
+	if validSize && (replay.Mines < 1 || replay.Mines >= replay.Rows*replay.Cols) {
+		v.add("mines", "must be between 1 and %d", replay.Rows*replay.Cols-1)
+		validSize = false
+	}
+
+	if validSize && len(replay.MinePositions) > 0 {
+		seen := make(map[types.Position]bool, len(replay.MinePositions))
+		for _, pos := range replay.MinePositions {
+			if pos.Row < 0 || pos.Row >= replay.Rows || pos.Col < 0 || pos.Col >= replay.Cols {
+				v.add("mine_positions", "must be on the board, %v is not", pos)
+				break
+			}
+			if seen[pos] {
+				v.add("mine_positions", "must not repeat %v", pos)
+				break
+			}
+			seen[pos] = true
+		}
+		if len(v.Fields) == 0 && len(replay.MinePositions) != replay.Mines {
+			v.add("mine_positions", "must list the %d mines", replay.Mines)
+		}
+	}
+
+	if step < 0 || step > len(replay.Moves) {
+		v.add("step", "must be between 0 and %d", len(replay.Moves))
+	}
+
+	return v.err()
+}
+
+// validateQuery checks the filters and page size of a listing.
+func validateQuery(query types.GameQuery) error {
+	v := &ValidationError{}
+
+	if _, known := transitions[query.Status]; query.Status != "" && !known {
+		v.add("status", "is not a game status")
+	}
+	if query.Rows < 0 {
+		v.add("rows", "must not be negative")
+	}
+	if query.Cols < 0 {
+		v.add("cols", "must not be negative")
+	}
+	if !query.CreatedAfter.IsZero() && !query.CreatedBefore.IsZero() && !query.CreatedAfter.Before(query.CreatedBefore) {
+		v.add("created_before", "must be after created_after")
+	}
+	if query.Limit < 0 || query.Limit > maxPageSize {
+		v.add("limit", "must be between 1 and %d", maxPageSize)
+	}
+
+	return v.err()
+}
+
+// validateCell checks that i, j is a cell of game.
+func validateCell(game *types.Game, i, j int) error {
+	v := &ValidationError{}
+	if i < 0 || i >= game.Rows {
+		v.add("row", "must be between 0 and %d", game.Rows-1)
+	}
+	if j < 0 || j >= game.Cols {
+		v.add("col", "must be between 0 and %d", game.Cols-1)
+	}
+	return v.err()
+}
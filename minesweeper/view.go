@@ -0,0 +1,103 @@
+package minesweeper
+
+import (
+	"time"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// View returns what the player may see of game. While the game is running
+// only revealed cells show their content and the seed is withheld, as it
+// would give the layout away. Finished games are shown in full, and paused
+// games without their board.
+func View(game *types.Game) *types.GameView {
+	finished := Finished(game.Status)
+
+	view := &types.GameView{
+		Name:           game.Name,
+		Owner:          game.Owner,
+		Parent:         game.Parent,
+		Difficulty:     game.Difficulty,
+		Rows:           game.Rows,
+		Cols:           game.Cols,
+		Mines:          game.Mines,
+		RemainingMines: game.RemainingMines,
+		Status:         game.Status,
+		StartedAt:      game.StartedAt,
+		EndedAt:        game.EndedAt,
+		Elapsed:        milliseconds(game.Elapsed),
+		TimeLimit:      game.TimeLimit,
+		Remaining:      remaining(game),
+		FirstClick:     game.FirstClick,
+		NoGuess:        game.NoGuess,
+		GuessFree:      game.GuessFree,
+		Hints:          game.Hints,
+		Ranked:         game.Ranked,
+		Undos:          game.Undos,
+		Version:        game.Version,
+	}
+	if finished {
+		view.Seed = game.Seed
+	}
+	if game.Grid == nil || game.Status == types.StatusPaused {
+		return view
+	}
+
+	cells := make([]types.CellView, game.Rows*game.Cols)
+	view.Grid = make([][]types.CellView, game.Rows)
+	for i := range view.Grid {
+		view.Grid[i] = cells[(game.Cols * i):(game.Cols * (i + 1))]
+		for j := range view.Grid[i] {
+			view.Grid[i][j] = CellView(game, i, j)
+		}
+	}
+	return view
+}
+
+// CellView returns what the player may see of the cell at i, j of game.
+func CellView(game *types.Game, i, j int) types.CellView {
+	cell := game.Grid[i][j]
+	view := types.CellView{Clicked: cell.Clicked, Mark: cell.Mark}
+	if cell.Clicked || Finished(game.Status) {
+		mine, value := cell.Mine, cell.Value
+		view.Mine = &mine
+		view.Value = &value
+	}
+	return view
+}
+
+// Summary describes game without its board.
+func Summary(game *types.Game) types.GameSummary {
+	return types.GameSummary{
+		Name:       game.Name,
+		Owner:      game.Owner,
+		Parent:     game.Parent,
+		Difficulty: game.Difficulty,
+		CreatedAt:  game.CreatedAt,
+		UpdatedAt:  game.UpdatedAt,
+		Rows:       game.Rows,
+		Cols:       game.Cols,
+		Mines:      game.Mines,
+		Status:     game.Status,
+		Elapsed:    milliseconds(game.Elapsed),
+		Remaining:  remaining(game),
+		Version:    game.Version,
+	}
+}
+
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+// remaining returns the milliseconds left to play game, nil when it has no
+// time limit.
+func remaining(game *types.Game) *int64 {
+	if game.TimeLimit <= 0 {
+		return nil
+	}
+	left := game.TimeLimit - milliseconds(game.Elapsed)
+	if left < 0 {
+		left = 0
+	}
+	return &left
+}
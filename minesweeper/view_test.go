@@ -0,0 +1,47 @@
+package minesweeper
+
+import (
+	"testing"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+func TestView(t *testing.T) {
+	game := &types.Game{
+		Name:   "test",
+		Rows:   2,
+		Cols:   2,
+		Mines:  1,
+		Seed:   42,
+		Status: types.StatusStarted,
+		Grid: []types.CellGrid{
+			{{Clicked: true, Value: 1}, {Value: 1}},
+			{{Value: 1, Mark: types.MarkQuestion}, {Mine: true, Mark: types.MarkFlag}},
+		},
+	}
+
+	view := View(game)
+	if view.Seed != 0 {
+		t.Error("the seed should be hidden while the game is running")
+	}
+	if cell := view.Grid[0][0]; !cell.Clicked || cell.Value == nil || *cell.Value != 1 || cell.Mine == nil || *cell.Mine {
+		t.Errorf("revealed cells should show their content, got=%+v", cell)
+	}
+	for _, pos := range []types.Position{{Row: 0, Col: 1}, {Row: 1, Col: 0}, {Row: 1, Col: 1}} {
+		if cell := view.Grid[pos.Row][pos.Col]; cell.Mine != nil || cell.Value != nil {
+			t.Errorf("cell %v should be hidden, got=%+v", pos, cell)
+		}
+	}
+	if view.Grid[1][1].Mark != types.MarkFlag || view.Grid[1][0].Mark != types.MarkQuestion {
+		t.Error("marks should be visible")
+	}
+
+	game.Status = types.StatusOver
+	view = View(game)
+	if view.Seed != 42 {
+		t.Errorf("the seed should be shown once the game is finished, got=%d", view.Seed)
+	}
+	if cell := view.Grid[1][1]; cell.Mine == nil || !*cell.Mine {
+		t.Errorf("mines should be shown once the game is finished, got=%+v", cell)
+	}
+}
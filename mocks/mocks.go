@@ -4,26 +4,100 @@ import "github.com/guilhermebr/minesweeper/types"
 
 type MockGameService struct {
 	OnCreate func(game *types.Game) error
-	OnStart  func(name string) (*types.Game, error)
-	OnClick  func(name string, i, j int) (*types.Game, error)
+	OnStart  func(name string, version int64) (*types.Game, error)
+	OnClick  func(name string, i, j int, version int64) (*types.Game, []types.Position, error)
+	OnChord  func(name string, i, j int, version int64) (*types.Game, []types.Position, error)
+	OnFlag   func(name string, i, j int, version int64) (*types.Game, error)
+	OnHint   func(name string, version int64) (*types.Game, *types.Hint, error)
+	OnGet    func(name string) (*types.Game, error)
+	OnDelete func(name string, version int64) error
+
+	OnSurrender func(name string, version int64) (*types.Game, error)
+	OnRestart   func(name string, version int64) (*types.Game, error)
+	OnFork      func(name, newName string) (*types.Game, error)
+	OnUndo      func(name string, moves int, version int64) (*types.Game, error)
+	OnPause     func(name string, version int64) (*types.Game, error)
+	OnResume    func(name string, version int64) (*types.Game, error)
+	OnReplay    func(name string) (*types.Replay, error)
+	OnList      func(query types.GameQuery) (*types.GamePage, error)
+
+	OnProbabilities func(name string) (*types.Probabilities, error)
 }
 
 func (m *MockGameService) Create(game *types.Game) error {
 	return m.OnCreate(game)
 }
 
-func (m *MockGameService) Start(name string) (*types.Game, error) {
-	return m.OnStart(name)
+func (m *MockGameService) Start(name string, version int64) (*types.Game, error) {
+	return m.OnStart(name, version)
+}
+
+func (m *MockGameService) Click(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+	return m.OnClick(name, i, j, version)
+}
+
+func (m *MockGameService) Chord(name string, i, j int, version int64) (*types.Game, []types.Position, error) {
+	return m.OnChord(name, i, j, version)
+}
+
+func (m *MockGameService) Flag(name string, i, j int, version int64) (*types.Game, error) {
+	return m.OnFlag(name, i, j, version)
+}
+
+func (m *MockGameService) Hint(name string, version int64) (*types.Game, *types.Hint, error) {
+	return m.OnHint(name, version)
+}
+
+func (m *MockGameService) Surrender(name string, version int64) (*types.Game, error) {
+	return m.OnSurrender(name, version)
+}
+
+func (m *MockGameService) Restart(name string, version int64) (*types.Game, error) {
+	return m.OnRestart(name, version)
+}
+
+func (m *MockGameService) Fork(name, newName string) (*types.Game, error) {
+	return m.OnFork(name, newName)
+}
+
+func (m *MockGameService) Undo(name string, moves int, version int64) (*types.Game, error) {
+	return m.OnUndo(name, moves, version)
+}
+
+func (m *MockGameService) Pause(name string, version int64) (*types.Game, error) {
+	return m.OnPause(name, version)
+}
+
+func (m *MockGameService) Resume(name string, version int64) (*types.Game, error) {
+	return m.OnResume(name, version)
+}
+
+func (m *MockGameService) Replay(name string) (*types.Replay, error) {
+	return m.OnReplay(name)
+}
+
+func (m *MockGameService) Delete(name string, version int64) error {
+	return m.OnDelete(name, version)
 }
 
-func (m *MockGameService) Click(name string, i, j int) (*types.Game, error) {
-	return m.OnClick(name, i, j)
+func (m *MockGameService) Get(name string) (*types.Game, error) {
+	return m.OnGet(name)
+}
+
+func (m *MockGameService) List(query types.GameQuery) (*types.GamePage, error) {
+	return m.OnList(query)
+}
+
+func (m *MockGameService) Probabilities(name string) (*types.Probabilities, error) {
+	return m.OnProbabilities(name)
 }
 
 type MockGameStore struct {
 	OnInsert    func(game *types.Game) error
 	OnUpdate    func(game *types.Game) error
 	OnGetByName func(name string) (*types.Game, error)
+	OnList      func(query types.GameQuery) (*types.GamePage, error)
+	OnDelete    func(name string) error
 }
 
 func (m *MockGameStore) Insert(game *types.Game) error {
@@ -37,3 +111,11 @@ func (m *MockGameStore) Update(game *types.Game) error {
 func (m *MockGameStore) GetByName(name string) (*types.Game, error) {
 	return m.OnGetByName(name)
 }
+
+func (m *MockGameStore) List(query types.GameQuery) (*types.GamePage, error) {
+	return m.OnList(query)
+}
+
+func (m *MockGameStore) Delete(name string) error {
+	return m.OnDelete(name)
+}
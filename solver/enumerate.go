@@ -0,0 +1,343 @@
+package solver
+
+import (
+	"fmt"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// maxSearchNodes bounds the backtracking done to enumerate a single frontier
+// component. Components that need more are left out of the enumeration.
+const maxSearchNodes = 1 << 20
+
+// component is a group of frontier cells linked by the numbers around them.
+// Arrangements of different components are independent of each other.
+type component struct {
+	cells       []types.Position
+	constraints []*constraint
+
+	// counts[k] is the number of arrangements of the component with k mines
+	// and cellCounts[k][x] how many of them put a mine on cells[x].
+	counts     []float64
+	cellCounts [][]float64
+	exact      bool
+}
+
+// components splits the frontier in independent groups of cells, returning
+// them in the order of their first constraint. Cells of each component are
+// ordered so that constraints are completed as early as possible while
+// enumerating.
+func components(cons []*constraint) []*component {
+	byCell := make(map[types.Position][]int)
+	for ci, c := range cons {
+		for _, pos := range c.cells {
+			byCell[pos] = append(byCell[pos], ci)
+		}
+	}
+
+	var comps []*component
+	visited := make([]bool, len(cons))
+	for start := range cons {
+		if visited[start] {
+			continue
+		}
+
+		comp := &component{}
+		seen := make(map[types.Position]bool)
+		queue := []int{start}
+		visited[start] = true
+		for len(queue) > 0 {
+			c := cons[queue[0]]
+			queue = queue[1:]
+			comp.constraints = append(comp.constraints, c)
+
+			for _, pos := range c.cells {
+				if seen[pos] {
+					continue
+				}
+				seen[pos] = true
+				comp.cells = append(comp.cells, pos)
+
+				for _, next := range byCell[pos] {
+					if !visited[next] {
+						visited[next] = true
+						queue = append(queue, next)
+					}
+				}
+			}
+		}
+		comps = append(comps, comp)
+	}
+	return comps
+}
+
+// assignment tracks a partial arrangement of mines on a component while it
+// is being enumerated or sampled.
+type assignment struct {
+	// need[ci] is the number of mines constraint ci still misses and free[ci]
+	// the number of its cells that are not assigned yet.
+	need   []int
+	free   []int
+	byCell [][]int
+	mines  []bool
+	k      int
+}
+
+func (c *component) newAssignment() *assignment {
+	index := make(map[types.Position]int, len(c.cells))
+	for x, pos := range c.cells {
+		index[pos] = x
+	}
+
+	a := &assignment{
+		need:   make([]int, len(c.constraints)),
+		free:   make([]int, len(c.constraints)),
+		byCell: make([][]int, len(c.cells)),
+		mines:  make([]bool, len(c.cells)),
+	}
+	for ci, con := range c.constraints {
+		a.need[ci] = con.mines
+		a.free[ci] = len(con.cells)
+		for _, pos := range con.cells {
+			x := index[pos]
+			a.byCell[x] = append(a.byCell[x], ci)
+		}
+	}
+	return a
+}
+
+// allows reports whether cell x can take isMine without breaking any of its
+// constraints.
+func (a *assignment) allows(x int, isMine bool) bool {
+	for _, ci := range a.byCell[x] {
+		left := a.need[ci]
+		if isMine {
+			left--
+		}
+		if left < 0 || left > a.free[ci]-1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *assignment) set(x int, isMine bool) {
+	for _, ci := range a.byCell[x] {
+		a.free[ci]--
+		if isMine {
+			a.need[ci]--
+		}
+	}
+	a.mines[x] = isMine
+	if isMine {
+		a.k++
+	}
+}
+
+func (a *assignment) unset(x int) {
+	isMine := a.mines[x]
+	for _, ci := range a.byCell[x] {
+		a.free[ci]++
+		if isMine {
+			a.need[ci]++
+		}
+	}
+	a.mines[x] = false
+	if isMine {
+		a.k--
+	}
+}
+
+func (c *component) resetCounts() {
+	n := len(c.cells)
+	c.counts = make([]float64, n+1)
+	c.cellCounts = make([][]float64, n+1)
+	for k := range c.cellCounts {
+		c.cellCounts[k] = make([]float64, n)
+	}
+}
+
+// add records the complete arrangement a with the given weight.
+func (c *component) add(a *assignment, weight float64) {
+	c.counts[a.k] += weight
+	for x, isMine := range a.mines {
+		if isMine {
+			c.cellCounts[a.k][x] += weight
+		}
+	}
+}
+
+// enumerate counts every arrangement of mines on the component cells that
+// satisfies all of its constraints. The component is left inexact when the
+// search is too large.
+func (c *component) enumerate() {
+	c.resetCounts()
+	a := c.newAssignment()
+	n := len(c.cells)
+
+	nodes := 0
+	var search func(x int) bool
+	search = func(x int) bool {
+		nodes++
+		if nodes > maxSearchNodes {
+			return false
+		}
+
+		if x == n {
+			c.add(a, 1)
+			return true
+		}
+
+		for _, isMine := range []bool{false, true} {
+			if !a.allows(x, isMine) {
+				continue
+			}
+
+			a.set(x, isMine)
+			complete := search(x + 1)
+			a.unset(x)
+			if !complete {
+				return false
+			}
+		}
+		return true
+	}
+	c.exact = search(0)
+}
+
+// feasibleSums returns which totals of frontier mines the components can
+// reach together, leaving out the component at skip. Components that were
+// not enumerated are assumed to hold any number of mines.
+func feasibleSums(comps []*component, skip int) []bool {
+	sums := []bool{true}
+	for ci, c := range comps {
+		if ci == skip {
+			continue
+		}
+
+		next := make([]bool, len(sums)+len(c.cells))
+		for total, ok := range sums {
+			if !ok {
+				continue
+			}
+			for k := 0; k <= len(c.cells); k++ {
+				if !c.exact || c.counts[k] > 0 {
+					next[total+k] = true
+				}
+			}
+		}
+		sums = next
+	}
+	return sums
+}
+
+// fits reports whether k mines on a component can be completed into a whole
+// board given the totals the other components can reach, the mines left and
+// the number of unconstrained cells.
+func fits(k int, others []bool, left, interior int) bool {
+	for total, ok := range others {
+		rest := left - k - total
+		if ok && rest >= 0 && rest <= interior {
+			return true
+		}
+	}
+	return false
+}
+
+// frontier splits the unknown cells in the enumerated components of cons and
+// the interior cells no number reaches.
+func (s *solver) frontier(cons []*constraint) ([]*component, []types.Position) {
+	comps := components(cons)
+	inFrontier := make(map[types.Position]bool)
+	for _, c := range comps {
+		c.enumerate()
+		for _, pos := range c.cells {
+			inFrontier[pos] = true
+		}
+	}
+
+	var interior []types.Position
+	for _, pos := range s.unknownCells() {
+		if !inFrontier[pos] {
+			interior = append(interior, pos)
+		}
+	}
+	return comps, interior
+}
+
+// applyEnumeration goes through every arrangement of mines on each frontier
+// component. A cell that is a mine in none of the arrangements compatible
+// with the numbers and the mines left is safe, and one that is a mine in all
+// of them is a mine. Cells away from the frontier are settled when the mines
+// left leave no choice for them.
+func (s *solver) applyEnumeration() bool {
+	cons := s.constraints()
+	if len(cons) == 0 {
+		return false
+	}
+
+	comps, interior := s.frontier(cons)
+	left := s.minesLeft()
+
+	progress := false
+	for ci, c := range comps {
+		if !c.exact {
+			continue
+		}
+
+		others := feasibleSums(comps, ci)
+		origins := formatOrigins(c.constraints)
+		for x, pos := range c.cells {
+			var localMine, localSafe, canMine, canSafe bool
+			for k, n := range c.counts {
+				if n == 0 {
+					continue
+				}
+				m := c.cellCounts[k][x]
+				localMine = localMine || m > 0
+				localSafe = localSafe || m < n
+				if fits(k, others, left, len(interior)) {
+					canMine = canMine || m > 0
+					canSafe = canSafe || m < n
+				}
+			}
+
+			switch {
+			case localSafe && !localMine:
+				progress = s.mark(pos, false, fmt.Sprintf("every arrangement of mines around the numbers at %s leaves %s safe", origins, formatPosition(pos))) || progress
+			case localMine && !localSafe:
+				progress = s.mark(pos, true, fmt.Sprintf("every arrangement of mines around the numbers at %s puts a mine on %s", origins, formatPosition(pos))) || progress
+			case canSafe && !canMine:
+				progress = s.mark(pos, false, fmt.Sprintf("with %d mines left, every arrangement around the numbers at %s leaves %s safe", left, origins, formatPosition(pos))) || progress
+			case canMine && !canSafe:
+				progress = s.mark(pos, true, fmt.Sprintf("with %d mines left, every arrangement around the numbers at %s puts a mine on %s", left, origins, formatPosition(pos))) || progress
+			}
+		}
+	}
+
+	if len(interior) == 0 {
+		return progress
+	}
+
+	var canMine, canSafe bool
+	for total, ok := range feasibleSums(comps, -1) {
+		rest := left - total
+		if !ok || rest < 0 || rest > len(interior) {
+			continue
+		}
+		canMine = canMine || rest > 0
+		canSafe = canSafe || rest < len(interior)
+	}
+
+	switch {
+	case canSafe && !canMine:
+		for _, pos := range interior {
+			progress = s.mark(pos, false, fmt.Sprintf("all %d mines left are needed next to the revealed numbers", left)) || progress
+		}
+	case canMine && !canSafe:
+		for _, pos := range interior {
+			progress = s.mark(pos, true, fmt.Sprintf("the %d mines left do not fit next to the revealed numbers", left)) || progress
+		}
+	}
+	return progress
+}
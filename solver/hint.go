@@ -0,0 +1,42 @@
+package solver
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// Hint returns a cell of game the player can open next. A provably safe cell
+// is returned whenever there is one; otherwise the cell least likely to hide
+// a mine is suggested along with its risk. It returns nil when there is no
+// unopened cell left to suggest.
+func Hint(game *types.Game) *types.Hint {
+	s := newSolver(Visible(game))
+	s.run()
+
+	if len(s.result.Safe) > 0 {
+		d := s.result.Safe[0]
+		return &types.Hint{Position: d.Position, Safe: true, Reason: d.Reason}
+	}
+	return s.guess()
+}
+
+// guess picks the unknown cell least likely to hide a mine.
+func (s *solver) guess() *types.Hint {
+	var hint *types.Hint
+	for _, cell := range s.probabilities(rand.New(rand.NewSource(1))).Cells {
+		if s.known[cell.Row][cell.Col] != unknown {
+			continue
+		}
+		if hint == nil || cell.Mine < hint.Risk {
+			hint = &types.Hint{Position: cell.Position, Risk: cell.Mine}
+		}
+	}
+	if hint == nil {
+		return nil
+	}
+
+	hint.Reason = fmt.Sprintf("no cell can be proven safe, %s is the least likely to hide a mine (%.0f%%)", formatPosition(hint.Position), hint.Risk*100)
+	return hint
+}
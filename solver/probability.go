@@ -0,0 +1,255 @@
+package solver
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// sampleBatches is the number of independent estimates made for boards too
+// large to be enumerated. Their spread gives the reported accuracy.
+const sampleBatches = 8
+
+// Probabilities returns the chance of every unopened cell of game hiding a
+// mine, given the revealed numbers and the total number of mines. Each
+// arrangement of the frontier is weighted by the number of ways the mines
+// left can be spread over the cells no number reaches. Frontier components
+// too large to be enumerated are sampled instead, and the result is marked as
+// inexact. Only the player view of game is used.
+func Probabilities(game *types.Game) *types.Probabilities {
+	s := newSolver(Visible(game))
+	s.run()
+	return s.probabilities(rand.New(rand.NewSource(1)))
+}
+
+func (s *solver) probabilities(rng *rand.Rand) *types.Probabilities {
+	comps, interior := s.frontier(s.constraints())
+	left := s.minesLeft()
+
+	var sampled []*component
+	for _, c := range comps {
+		if !c.exact {
+			sampled = append(sampled, c)
+		}
+	}
+	batches := 1
+	if len(sampled) > 0 {
+		batches = sampleBatches
+	}
+
+	sum := make(map[types.Position]float64)
+	sumSq := make(map[types.Position]float64)
+	done := 0
+	for b := 0; b < batches; b++ {
+		for _, c := range sampled {
+			c.sample(rng, maxSearchNodes/(sampleBatches*len(c.cells)))
+		}
+
+		probs, ok := combine(comps, interior, left)
+		if !ok {
+			continue
+		}
+		for pos, p := range probs {
+			sum[pos] += p
+			sumSq[pos] += p * p
+		}
+		done++
+	}
+
+	result := &types.Probabilities{Exact: len(sampled) == 0}
+	density := float64(left) / float64(len(s.unknownCells()))
+	for i := range s.known {
+		for j, k := range s.known[i] {
+			pos := types.Position{Row: i, Col: j}
+			cell := types.CellProbability{Position: pos}
+			switch {
+			case k == open:
+				continue
+			case k == mine:
+				cell.Mine = 1
+			case k == safe:
+				cell.Mine = 0
+			case done == 0:
+				// Every sample hit a dead end, nothing better than the
+				// density of mines left is known.
+				cell.Mine = density
+				result.Accuracy = math.Max(result.Accuracy, 0.5)
+			default:
+				cell.Mine = sum[pos] / float64(done)
+				if done > 1 {
+					variance := (sumSq[pos] - sum[pos]*sum[pos]/float64(done)) / float64(done-1)
+					stdErr := math.Sqrt(math.Max(variance, 0) / float64(done))
+					result.Accuracy = math.Max(result.Accuracy, stdErr)
+				}
+			}
+			result.Cells = append(result.Cells, cell)
+		}
+	}
+	return result
+}
+
+// combine puts together the arrangement counts of every component and the
+// number of ways to spread the remaining mines over the interior cells. It
+// returns false when no arrangement was found.
+func combine(comps []*component, interior []types.Position, left int) (map[types.Position]float64, bool) {
+	total := 0
+	for _, c := range comps {
+		total += len(c.cells)
+	}
+
+	// weights[t] is proportional to the ways of laying the mines not on the
+	// frontier over the interior when the frontier holds t mines.
+	weights := make([]float64, total+1)
+	maxLog := math.Inf(-1)
+	logs := make([]float64, total+1)
+	for t := range logs {
+		rest := left - t
+		if rest < 0 || rest > len(interior) {
+			logs[t] = math.Inf(-1)
+			continue
+		}
+		logs[t] = logChoose(len(interior), rest)
+		maxLog = math.Max(maxLog, logs[t])
+	}
+	if math.IsInf(maxLog, -1) {
+		return nil, false
+	}
+	for t, l := range logs {
+		weights[t] = math.Exp(l - maxLog)
+	}
+
+	// Counts are scaled to keep them in range, which does not change any
+	// probability as every board takes exactly one arrangement of each
+	// component.
+	counts := make([][]float64, len(comps))
+	cellCounts := make([][][]float64, len(comps))
+	for ci, c := range comps {
+		scale := 0.0
+		for _, n := range c.counts {
+			scale = math.Max(scale, n)
+		}
+		if scale == 0 {
+			return nil, false
+		}
+
+		counts[ci] = make([]float64, len(c.counts))
+		cellCounts[ci] = make([][]float64, len(c.counts))
+		for k, n := range c.counts {
+			counts[ci][k] = n / scale
+			cellCounts[ci][k] = make([]float64, len(c.cells))
+			for x, m := range c.cellCounts[k] {
+				cellCounts[ci][k][x] = m / scale
+			}
+		}
+	}
+
+	all := convolve(counts, -1)
+	z := 0.0
+	for t, n := range all {
+		z += n * weights[t]
+	}
+	if z == 0 {
+		return nil, false
+	}
+
+	probs := make(map[types.Position]float64)
+	if len(interior) > 0 {
+		p := 0.0
+		for t, n := range all {
+			p += n * weights[t] * float64(left-t) / float64(len(interior))
+		}
+		for _, pos := range interior {
+			probs[pos] = p / z
+		}
+	}
+
+	for ci, c := range comps {
+		others := convolve(counts, ci)
+		for x, pos := range c.cells {
+			p := 0.0
+			for k := range counts[ci] {
+				m := cellCounts[ci][k][x]
+				if m == 0 {
+					continue
+				}
+				for o, n := range others {
+					if k+o < len(weights) {
+						p += m * n * weights[k+o]
+					}
+				}
+			}
+			probs[pos] = p / z
+		}
+	}
+	return probs, true
+}
+
+// convolve returns how many ways each total of mines can be reached by the
+// components, leaving out the one at skip.
+func convolve(counts [][]float64, skip int) []float64 {
+	result := []float64{1}
+	for ci, c := range counts {
+		if ci == skip {
+			continue
+		}
+
+		next := make([]float64, len(result)+len(c)-1)
+		for t, n := range result {
+			if n == 0 {
+				continue
+			}
+			for k, m := range c {
+				next[t+k] += n * m
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// sample estimates the arrangement counts of a component too large to be
+// enumerated by walking random paths down the search tree. Each complete
+// path is weighted by the product of the choices available along it, which
+// makes the estimates unbiased.
+func (c *component) sample(rng *rand.Rand, samples int) {
+	if samples < 1 {
+		samples = 1
+	}
+
+	c.resetCounts()
+	a := c.newAssignment()
+	choices := make([]bool, 0, 2)
+	for n := 0; n < samples; n++ {
+		weight := 1.0
+		x := 0
+		for ; x < len(c.cells); x++ {
+			choices = choices[:0]
+			for _, isMine := range []bool{false, true} {
+				if a.allows(x, isMine) {
+					choices = append(choices, isMine)
+				}
+			}
+			if len(choices) == 0 {
+				break
+			}
+
+			weight *= float64(len(choices))
+			a.set(x, choices[rng.Intn(len(choices))])
+		}
+
+		if x == len(c.cells) {
+			c.add(a, weight/float64(samples))
+		}
+		for x--; x >= 0; x-- {
+			a.unset(x)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package solver
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+func TestProbabilities_Simple(t *testing.T) {
+	game := newGame(1,
+		"*1..",
+	)
+
+	probs := Probabilities(game)
+
+	if !probs.Exact {
+		t.Error("small boards should be analysed exactly")
+	}
+	expected := map[types.Position]float64{
+		{Row: 0, Col: 0}: 0.5,
+		{Row: 0, Col: 2}: 0.5,
+		{Row: 0, Col: 3}: 0,
+	}
+	if len(probs.Cells) != len(expected) {
+		t.Fatalf("unexpected cells. want=%d, got=%d", len(expected), len(probs.Cells))
+	}
+	for _, cell := range probs.Cells {
+		if want := expected[cell.Position]; math.Abs(cell.Mine-want) > 1e-9 {
+			t.Errorf("cell %v: unexpected probability. want=%v, got=%v", cell.Position, want, cell.Mine)
+		}
+	}
+}
+
+// TestProbabilities_Interior checks that frontier arrangements are weighted
+// by the ways of placing the remaining mines on the cells no number reaches.
+func TestProbabilities_Interior(t *testing.T) {
+	game := newGame(2,
+		".1.",
+		"*..",
+		"...",
+		"..*",
+	)
+
+	probs := Probabilities(game)
+	brute := bruteProbabilities(game)
+	for _, cell := range probs.Cells {
+		if math.Abs(cell.Mine-brute[cell.Position]) > 1e-9 {
+			t.Errorf("cell %v: unexpected probability. want=%v, got=%v", cell.Position, brute[cell.Position], cell.Mine)
+		}
+	}
+}
+
+func TestProbabilities_BruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for n := 0; n < 200; n++ {
+		rows, cols := 2+rng.Intn(3), 2+rng.Intn(3)
+		mines := 1 + rng.Intn(rows*cols/2)
+		game := randomGame(rng, rows, cols, mines)
+
+		probs := Probabilities(game)
+		brute := bruteProbabilities(game)
+
+		if !probs.Exact || probs.Accuracy != 0 {
+			t.Fatalf("board %d: small boards should be analysed exactly", n)
+		}
+		if len(probs.Cells) != len(brute) {
+			t.Fatalf("board %d: unexpected cells. want=%d, got=%d", n, len(brute), len(probs.Cells))
+		}
+		for _, cell := range probs.Cells {
+			if math.Abs(cell.Mine-brute[cell.Position]) > 1e-9 {
+				t.Fatalf("board %d: cell %v: unexpected probability. want=%v, got=%v\n%s", n, cell.Position, brute[cell.Position], cell.Mine, render(game))
+			}
+		}
+	}
+}
+
+func TestProbabilities_Sampled(t *testing.T) {
+	// A single open row between two closed ones makes a frontier far too
+	// large to enumerate.
+	rng := rand.New(rand.NewSource(3))
+	game := newGame(0,
+		strings.Repeat(".", 80),
+		strings.Repeat("0", 80),
+		strings.Repeat(".", 80),
+	)
+	s := &solver{game: game}
+	for _, i := range []int{0, 2} {
+		for j := range game.Grid[i] {
+			if rng.Intn(3) != 0 {
+				continue
+			}
+			game.Mines++
+			game.Grid[i][j].Mine = true
+			s.forEachNeighbour(i, j, func(z, w int) {
+				game.Grid[z][w].Value++
+			})
+		}
+	}
+
+	probs := Probabilities(game)
+
+	if probs.Exact {
+		t.Fatal("large frontiers should be sampled")
+	}
+	if probs.Accuracy <= 0 || probs.Accuracy > 0.1 {
+		t.Errorf("unexpected accuracy: %v", probs.Accuracy)
+	}
+
+	total := 0.0
+	for _, cell := range probs.Cells {
+		if cell.Mine < 0 || cell.Mine > 1 {
+			t.Fatalf("cell %v: probability out of range: %v", cell.Position, cell.Mine)
+		}
+		total += cell.Mine
+	}
+	if math.Abs(total-float64(game.Mines)) > 0.1*float64(game.Mines) {
+		t.Errorf("probabilities should add up to about %d mines, got %v", game.Mines, total)
+	}
+}
+
+func TestComponentSample(t *testing.T) {
+	game := newGame(4,
+		"......",
+		"121121",
+	)
+	s := newSolver(Visible(game))
+	comps := components(s.constraints())
+	if len(comps) != 1 {
+		t.Fatalf("unexpected components. want=1, got=%d", len(comps))
+	}
+
+	c := comps[0]
+	c.enumerate()
+	exact := append([]float64(nil), c.counts...)
+
+	c.sample(rand.New(rand.NewSource(4)), 20000)
+	for k, want := range exact {
+		if math.Abs(c.counts[k]-want) > 0.1*want+0.05 {
+			t.Errorf("%d mines: unexpected estimate. want=%v, got=%v", k, want, c.counts[k])
+		}
+	}
+}
+
+// bruteProbabilities returns the share of layouts compatible with the
+// revealed cells of game that put a mine on each hidden cell.
+func bruteProbabilities(game *types.Game) map[types.Position]float64 {
+	var hidden []types.Position
+	for i := range game.Grid {
+		for j, cell := range game.Grid[i] {
+			if !cell.Clicked {
+				hidden = append(hidden, types.Position{Row: i, Col: j})
+			}
+		}
+	}
+
+	s := &solver{game: game}
+	layouts := 0
+	mines := make(map[types.Position]int)
+	layout := make(map[types.Position]bool)
+	for mask := 0; mask < 1<<uint(len(hidden)); mask++ {
+		count := 0
+		for k, pos := range hidden {
+			layout[pos] = mask&(1<<uint(k)) != 0
+			if layout[pos] {
+				count++
+			}
+		}
+		if count != game.Mines {
+			continue
+		}
+
+		valid := true
+		for i := range game.Grid {
+			for j, cell := range game.Grid[i] {
+				if !cell.Clicked {
+					continue
+				}
+				around := 0
+				s.forEachNeighbour(i, j, func(z, w int) {
+					if layout[types.Position{Row: z, Col: w}] {
+						around++
+					}
+				})
+				if around != cell.Value {
+					valid = false
+				}
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		layouts++
+		for _, pos := range hidden {
+			if layout[pos] {
+				mines[pos]++
+			}
+		}
+	}
+
+	probs := make(map[types.Position]float64)
+	for _, pos := range hidden {
+		probs[pos] = float64(mines[pos]) / float64(layouts)
+	}
+	return probs
+}
@@ -0,0 +1,336 @@
+// Package solver deduces which unrevealed cells of a minesweeper board are
+// provably safe and which are provably mines, using only what a player can
+// see: the revealed cells, their values and the total number of mines.
+package solver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// Deduction is a cell whose content is proven by the revealed numbers.
+type Deduction struct {
+	types.Position
+	Mine   bool   `json:"mine"`
+	Reason string `json:"reason"`
+}
+
+// Result holds every cell the solver could prove, in the order it proved them.
+type Result struct {
+	Safe  []Deduction `json:"safe"`
+	Mines []Deduction `json:"mines"`
+}
+
+type knowledge int8
+
+const (
+	unknown knowledge = iota
+	open
+	safe
+	mine
+)
+
+// Visible returns the player view of game: dimensions, total mines and the
+// revealed cells with their values. Unrevealed cells are left blank, so
+// nothing computed from the view can depend on hidden mines.
+func Visible(game *types.Game) *types.Game {
+	view := &types.Game{
+		Name:  game.Name,
+		Rows:  game.Rows,
+		Cols:  game.Cols,
+		Mines: game.Mines,
+	}
+	if game.Grid == nil {
+		return view
+	}
+
+	cells := make(types.CellGrid, game.Rows*game.Cols)
+	view.Grid = make([]types.CellGrid, game.Rows)
+	for i := range view.Grid {
+		view.Grid[i] = cells[(game.Cols * i):(game.Cols * (i + 1))]
+		for j, cell := range game.Grid[i] {
+			if cell.Clicked {
+				view.Grid[i][j] = types.Cell{Mine: cell.Mine, Clicked: true, Value: cell.Value}
+			}
+		}
+	}
+	return view
+}
+
+// Solve returns the cells of game that are provably safe or provably mines.
+// Only the player view of game is used.
+func Solve(game *types.Game) *Result {
+	s := newSolver(Visible(game))
+	s.run()
+	return s.result
+}
+
+type solver struct {
+	game   *types.Game
+	known  [][]knowledge
+	result *Result
+}
+
+func newSolver(game *types.Game) *solver {
+	s := &solver{
+		game:   game,
+		known:  make([][]knowledge, game.Rows),
+		result: &Result{},
+	}
+	for i := range s.known {
+		s.known[i] = make([]knowledge, game.Cols)
+		if game.Grid == nil {
+			continue
+		}
+		for j, cell := range game.Grid[i] {
+			switch {
+			case cell.Clicked && cell.Mine:
+				s.known[i][j] = mine
+			case cell.Clicked:
+				s.known[i][j] = open
+			}
+		}
+	}
+	return s
+}
+
+// run applies the rules from the cheapest to the most expensive, going back
+// to the cheap ones every time something new is proven.
+func (s *solver) run() {
+	progress := true
+	for progress {
+		progress = s.applySingle() || s.applySubsets() || s.applyMineCount() || s.applyEnumeration()
+	}
+}
+
+func (s *solver) mark(pos types.Position, isMine bool, reason string) bool {
+	if s.known[pos.Row][pos.Col] != unknown {
+		return false
+	}
+
+	d := Deduction{Position: pos, Mine: isMine, Reason: reason}
+	if isMine {
+		s.known[pos.Row][pos.Col] = mine
+		s.result.Mines = append(s.result.Mines, d)
+	} else {
+		s.known[pos.Row][pos.Col] = safe
+		s.result.Safe = append(s.result.Safe, d)
+	}
+	return true
+}
+
+// constraint says that exactly mines of cells hide a mine, as shown by the
+// number at origin.
+type constraint struct {
+	origin types.Position
+	value  int
+	cells  []types.Position
+	mines  int
+}
+
+func (c *constraint) String() string {
+	return fmt.Sprintf("the %d at %s", c.value, formatPosition(c.origin))
+}
+
+// constraints returns one constraint for every revealed number that still
+// borders unknown cells.
+func (s *solver) constraints() []*constraint {
+	var cons []*constraint
+	for i := 0; i < s.game.Rows; i++ {
+		for j := 0; j < s.game.Cols; j++ {
+			if s.known[i][j] != open {
+				continue
+			}
+
+			c := &constraint{
+				origin: types.Position{Row: i, Col: j},
+				value:  s.game.Grid[i][j].Value,
+				mines:  s.game.Grid[i][j].Value,
+			}
+			s.forEachNeighbour(i, j, func(z, w int) {
+				switch s.known[z][w] {
+				case unknown:
+					c.cells = append(c.cells, types.Position{Row: z, Col: w})
+				case mine:
+					c.mines--
+				}
+			})
+			if len(c.cells) > 0 {
+				cons = append(cons, c)
+			}
+		}
+	}
+	return cons
+}
+
+// applySingle looks at every number on its own: a number with all its mines
+// found has only safe neighbours left, and a number with as many unknown
+// neighbours as missing mines has only mines left.
+func (s *solver) applySingle() bool {
+	progress := false
+	for _, c := range s.constraints() {
+		switch {
+		case c.mines == 0:
+			for _, pos := range c.cells {
+				progress = s.mark(pos, false, fmt.Sprintf("%s is already satisfied", c)) || progress
+			}
+		case c.mines == len(c.cells):
+			for _, pos := range c.cells {
+				progress = s.mark(pos, true, fmt.Sprintf("%s needs all of its %d unopened neighbours", c, len(c.cells))) || progress
+			}
+		}
+	}
+	return progress
+}
+
+// applySubsets compares pairs of numbers: when the unknown neighbours of one
+// are all shared with another, the cells only the second one touches must
+// hold the difference between their missing mines.
+func (s *solver) applySubsets() bool {
+	cons := s.constraints()
+	progress := false
+	for _, a := range cons {
+		for _, b := range cons {
+			if a == b || len(a.cells) >= len(b.cells) || !isSubset(a.cells, b.cells) {
+				continue
+			}
+
+			diff := difference(b.cells, a.cells)
+			switch b.mines - a.mines {
+			case 0:
+				for _, pos := range diff {
+					progress = s.mark(pos, false, fmt.Sprintf("%s already accounts for every mine %s is missing", a, b)) || progress
+				}
+			case len(diff):
+				for _, pos := range diff {
+					progress = s.mark(pos, true, fmt.Sprintf("%s needs %d more mines than fit next to %s", b, len(diff), a)) || progress
+				}
+			}
+		}
+	}
+	return progress
+}
+
+// applyMineCount settles every unknown cell when the mines left are none or
+// exactly as many as the unknown cells.
+func (s *solver) applyMineCount() bool {
+	cells := s.unknownCells()
+	left := s.minesLeft()
+
+	switch {
+	case len(cells) == 0:
+		return false
+	case left == 0:
+		for _, pos := range cells {
+			s.mark(pos, false, "every mine has already been found")
+		}
+		return true
+	case left == len(cells):
+		for _, pos := range cells {
+			s.mark(pos, true, fmt.Sprintf("the %d mines left fill every unopened cell", left))
+		}
+		return true
+	}
+	return false
+}
+
+func (s *solver) unknownCells() []types.Position {
+	var cells []types.Position
+	for i := range s.known {
+		for j, k := range s.known[i] {
+			if k == unknown {
+				cells = append(cells, types.Position{Row: i, Col: j})
+			}
+		}
+	}
+	return cells
+}
+
+func (s *solver) minesLeft() int {
+	left := s.game.Mines
+	for i := range s.known {
+		for _, k := range s.known[i] {
+			if k == mine {
+				left--
+			}
+		}
+	}
+	return left
+}
+
+func (s *solver) forEachNeighbour(i, j int, fn func(z, w int)) {
+	for z := i - 1; z < i+2; z++ {
+		if z < 0 || z > s.game.Rows-1 {
+			continue
+		}
+		for w := j - 1; w < j+2; w++ {
+			if w < 0 || w > s.game.Cols-1 {
+				continue
+			}
+			if z == i && w == j {
+				continue
+			}
+			fn(z, w)
+		}
+	}
+}
+
+// isSubset reports whether every cell of a is in b. Both are sorted in row
+// major order, as built by constraints.
+func isSubset(a, b []types.Position) bool {
+	k := 0
+	for _, pos := range a {
+		for k < len(b) && less(b[k], pos) {
+			k++
+		}
+		if k == len(b) || b[k] != pos {
+			return false
+		}
+	}
+	return true
+}
+
+// difference returns the cells of b that are not in a.
+func difference(b, a []types.Position) []types.Position {
+	in := make(map[types.Position]bool, len(a))
+	for _, pos := range a {
+		in[pos] = true
+	}
+
+	var diff []types.Position
+	for _, pos := range b {
+		if !in[pos] {
+			diff = append(diff, pos)
+		}
+	}
+	return diff
+}
+
+func less(a, b types.Position) bool {
+	return a.Row < b.Row || (a.Row == b.Row && a.Col < b.Col)
+}
+
+func formatPosition(pos types.Position) string {
+	return fmt.Sprintf("(%d,%d)", pos.Row, pos.Col)
+}
+
+// formatOrigins lists the numbers behind a set of constraints for a reason.
+func formatOrigins(cons []*constraint) string {
+	const maxListed = 3
+
+	parts := make([]string, 0, maxListed)
+	for i, c := range cons {
+		if i == maxListed {
+			break
+		}
+		parts = append(parts, formatPosition(c.origin))
+	}
+
+	list := strings.Join(parts, ", ")
+	if len(cons) > maxListed {
+		list = fmt.Sprintf("%s and %d more", list, len(cons)-maxListed)
+	}
+	return list
+}
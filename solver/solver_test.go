@@ -0,0 +1,341 @@
+package solver
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// newGame builds a game from rows of cells where '*' is a hidden mine, '.' a
+// hidden safe cell, 'x' a revealed mine and a digit a revealed number.
+func newGame(mines int, rows ...string) *types.Game {
+	game := &types.Game{
+		Rows:  len(rows),
+		Cols:  len(rows[0]),
+		Mines: mines,
+		Grid:  make([]types.CellGrid, len(rows)),
+	}
+	for i, row := range rows {
+		game.Grid[i] = make(types.CellGrid, len(row))
+		for j, c := range row {
+			cell := &game.Grid[i][j]
+			switch {
+			case c == '*':
+				cell.Mine = true
+			case c == 'x':
+				cell.Mine = true
+				cell.Clicked = true
+			case c >= '0' && c <= '8':
+				cell.Clicked = true
+				cell.Value = int(c - '0')
+			}
+		}
+	}
+	return game
+}
+
+func positions(ds []Deduction) map[types.Position]bool {
+	set := make(map[types.Position]bool)
+	for _, d := range ds {
+		set[d.Position] = true
+	}
+	return set
+}
+
+func TestVisible(t *testing.T) {
+	game := newGame(1,
+		"1*",
+		"..",
+	)
+	game.Grid[1][1].Mark = types.MarkFlag
+
+	view := Visible(game)
+	if view.Grid[0][1].Mine {
+		t.Error("hidden mine should not be visible")
+	}
+	if view.Grid[1][1].Mark != types.MarkNone {
+		t.Error("marks should not be visible to the solver")
+	}
+	if cell := view.Grid[0][0]; !cell.Clicked || cell.Value != 1 {
+		t.Errorf("unexpected revealed cell. want={clicked 1}, got=%+v", cell)
+	}
+}
+
+func TestSolve_Single(t *testing.T) {
+	game := newGame(1,
+		"1*.",
+	)
+
+	result := Solve(game)
+
+	if len(result.Mines) != 1 || result.Mines[0].Position != (types.Position{Row: 0, Col: 1}) {
+		t.Fatalf("unexpected mines. want=[(0,1)], got=%v", result.Mines)
+	}
+	if !strings.Contains(result.Mines[0].Reason, "the 1 at (0,0)") {
+		t.Errorf("unexpected reason: %s", result.Mines[0].Reason)
+	}
+	if len(result.Safe) != 1 || result.Safe[0].Position != (types.Position{Row: 0, Col: 2}) {
+		t.Fatalf("unexpected safe cells. want=[(0,2)], got=%v", result.Safe)
+	}
+}
+
+func TestSolve_Satisfied(t *testing.T) {
+	game := newGame(1,
+		"x1.",
+		"11.",
+		"...",
+	)
+
+	result := Solve(game)
+
+	safe := positions(result.Safe)
+	for _, pos := range []types.Position{{Row: 0, Col: 2}, {Row: 1, Col: 2}, {Row: 2, Col: 0}, {Row: 2, Col: 1}, {Row: 2, Col: 2}} {
+		if !safe[pos] {
+			t.Errorf("cell %v should be safe", pos)
+		}
+	}
+	if !strings.Contains(result.Safe[0].Reason, "is already satisfied") {
+		t.Errorf("unexpected reason: %s", result.Safe[0].Reason)
+	}
+}
+
+func TestSolve_Subset(t *testing.T) {
+	game := newGame(2,
+		"*.*.",
+		"121.",
+	)
+
+	result := Solve(game)
+
+	mines := positions(result.Mines)
+	if !mines[types.Position{Row: 0, Col: 0}] || !mines[types.Position{Row: 0, Col: 2}] {
+		t.Errorf("unexpected mines. want=(0,0) and (0,2), got=%v", result.Mines)
+	}
+	safe := positions(result.Safe)
+	if !safe[types.Position{Row: 0, Col: 1}] {
+		t.Errorf("cell (0,1) should be safe, got=%v", result.Safe)
+	}
+}
+
+func TestSolve_MineCount(t *testing.T) {
+	game := newGame(1,
+		"*1..",
+	)
+
+	result := Solve(game)
+
+	// The single mine must be next to the 1, so the cell no number reaches
+	// is safe.
+	if len(result.Safe) != 1 || result.Safe[0].Position != (types.Position{Row: 0, Col: 3}) {
+		t.Errorf("unexpected safe cells. want=[(0,3)], got=%v", result.Safe)
+	}
+	if len(result.Mines) != 0 {
+		t.Errorf("no mine can be proven, got=%v", result.Mines)
+	}
+}
+
+func TestSolve_NothingRevealed(t *testing.T) {
+	game := &types.Game{Rows: 3, Cols: 3, Mines: 2}
+
+	result := Solve(game)
+
+	if len(result.Safe) != 0 || len(result.Mines) != 0 {
+		t.Errorf("nothing can be proven on a closed board, got=%+v", result)
+	}
+}
+
+func TestHint_Safe(t *testing.T) {
+	game := newGame(1,
+		"1*.",
+	)
+
+	hint := Hint(game)
+
+	if !hint.Safe || hint.Position != (types.Position{Row: 0, Col: 2}) {
+		t.Fatalf("unexpected hint. want=safe (0,2), got=%+v", hint)
+	}
+	if hint.Reason == "" {
+		t.Error("hint should have a reason")
+	}
+}
+
+func TestHint_Guess(t *testing.T) {
+	game := newGame(2,
+		"1*..",
+		"....",
+		"....",
+		"...*",
+	)
+
+	hint := Hint(game)
+
+	if hint.Safe {
+		t.Fatalf("no cell can be proven safe, got=%+v", hint)
+	}
+	if hint.Row < 2 && hint.Col < 2 {
+		t.Errorf("cells away from the 1 are the least risky, got=%+v", hint)
+	}
+	if !strings.Contains(hint.Reason, "no cell can be proven safe") {
+		t.Errorf("unexpected reason: %s", hint.Reason)
+	}
+}
+
+// TestSolve_BruteForce compares the solver with every layout of mines that
+// matches the revealed cells of small random boards: a cell must be proven
+// exactly when it holds the same content in all of them.
+func TestSolve_BruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 300; n++ {
+		rows, cols := 2+rng.Intn(3), 2+rng.Intn(3)
+		mines := 1 + rng.Intn(rows*cols/2)
+		game := randomGame(rng, rows, cols, mines)
+
+		result := Solve(game)
+		safe, mine := forcedCells(game)
+
+		if got := positions(result.Safe); !equalSets(got, safe) {
+			t.Fatalf("board %d: unexpected safe cells. want=%v, got=%v\n%s", n, safe, got, render(game))
+		}
+		if got := positions(result.Mines); !equalSets(got, mine) {
+			t.Fatalf("board %d: unexpected mines. want=%v, got=%v\n%s", n, mine, got, render(game))
+		}
+	}
+}
+
+func randomGame(rng *rand.Rand, rows, cols, mines int) *types.Game {
+	game := &types.Game{Rows: rows, Cols: cols, Mines: mines, Grid: make([]types.CellGrid, rows)}
+	for i := range game.Grid {
+		game.Grid[i] = make(types.CellGrid, cols)
+	}
+	for placed := 0; placed < mines; {
+		i, j := rng.Intn(rows), rng.Intn(cols)
+		if !game.Grid[i][j].Mine {
+			game.Grid[i][j].Mine = true
+			placed++
+		}
+	}
+
+	s := &solver{game: game}
+	for i := range game.Grid {
+		for j := range game.Grid[i] {
+			s.forEachNeighbour(i, j, func(z, w int) {
+				if game.Grid[z][w].Mine {
+					game.Grid[i][j].Value++
+				}
+			})
+		}
+	}
+
+	for i := range game.Grid {
+		for j := range game.Grid[i] {
+			if !game.Grid[i][j].Mine && rng.Intn(3) == 0 {
+				game.Grid[i][j].Clicked = true
+			}
+		}
+	}
+	return game
+}
+
+// forcedCells enumerates every layout of mines compatible with the revealed
+// cells of game and returns the hidden cells that are safe, and the ones that
+// are mines, in all of them.
+func forcedCells(game *types.Game) (map[types.Position]bool, map[types.Position]bool) {
+	var hidden []types.Position
+	for i := range game.Grid {
+		for j, cell := range game.Grid[i] {
+			if !cell.Clicked {
+				hidden = append(hidden, types.Position{Row: i, Col: j})
+			}
+		}
+	}
+
+	s := &solver{game: game}
+	everMine := make(map[types.Position]bool)
+	everSafe := make(map[types.Position]bool)
+	layout := make(map[types.Position]bool)
+	for mask := 0; mask < 1<<uint(len(hidden)); mask++ {
+		count := 0
+		for k, pos := range hidden {
+			layout[pos] = mask&(1<<uint(k)) != 0
+			if layout[pos] {
+				count++
+			}
+		}
+		if count != game.Mines {
+			continue
+		}
+
+		valid := true
+		for i := range game.Grid {
+			for j, cell := range game.Grid[i] {
+				if !cell.Clicked {
+					continue
+				}
+				around := 0
+				s.forEachNeighbour(i, j, func(z, w int) {
+					if layout[types.Position{Row: z, Col: w}] {
+						around++
+					}
+				})
+				if around != cell.Value {
+					valid = false
+				}
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		for _, pos := range hidden {
+			if layout[pos] {
+				everMine[pos] = true
+			} else {
+				everSafe[pos] = true
+			}
+		}
+	}
+
+	safe := make(map[types.Position]bool)
+	mine := make(map[types.Position]bool)
+	for _, pos := range hidden {
+		switch {
+		case everSafe[pos] && !everMine[pos]:
+			safe[pos] = true
+		case everMine[pos] && !everSafe[pos]:
+			mine[pos] = true
+		}
+	}
+	return safe, mine
+}
+
+func equalSets(a, b map[types.Position]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for pos := range a {
+		if !b[pos] {
+			return false
+		}
+	}
+	return true
+}
+
+func render(game *types.Game) string {
+	var b strings.Builder
+	for _, row := range game.Grid {
+		for _, cell := range row {
+			switch {
+			case cell.Clicked:
+				b.WriteByte(byte('0' + cell.Value))
+			case cell.Mine:
+				b.WriteByte('*')
+			default:
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// cursor is the position of a game in a listing. It is handed to clients as
+// an opaque string.
+type cursor struct {
+	createdAt time.Time
+	name      string
+}
+
+func cursorOf(game *types.Game) cursor {
+	return cursor{createdAt: game.CreatedAt, name: game.Name}
+}
+
+// before reports whether game is listed after c.
+func (c cursor) before(game *types.Game) bool {
+	if !c.createdAt.Equal(game.CreatedAt) {
+		return c.createdAt.Before(game.CreatedAt)
+	}
+	return c.name < game.Name
+}
+
+func (c cursor) encode() string {
+	raw := strconv.FormatInt(c.createdAt.UnixNano(), 10) + "/" + c.name
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, err
+	}
+
+	parts := strings.SplitN(string(raw), "/", 2)
+	if len(parts) != 2 {
+		return cursor{}, errors.New("missing cursor name")
+	}
+	nsec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return cursor{}, err
+	}
+	return cursor{createdAt: time.Unix(0, nsec), name: parts[1]}, nil
+}
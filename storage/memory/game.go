@@ -2,10 +2,21 @@ package memory
 
 import (
 	"errors"
+	"sort"
 
 	"github.com/guilhermebr/minesweeper/types"
 )
 
+// Errors returned by GameStore.
+var (
+	ErrGameNotFound  = errors.New("game not found")
+	ErrGameExists    = errors.New("game already exists")
+	ErrConflict      = errors.New("game was updated concurrently")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// GameStore keeps its own copy of every game: games passed in or handed out
+// can be changed freely without affecting what is stored.
 type GameStore struct {
 	db *DB
 }
@@ -15,26 +26,112 @@ func NewGameStore(db *DB) *GameStore {
 }
 
 func (s *GameStore) Insert(game *types.Game) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
 	if _, ok := s.db.games[game.Name]; ok {
-		return errors.New("game already exist")
+		return ErrGameExists
 	}
-	s.db.games[game.Name] = game
+	game.Version = 1
+	s.db.games[game.Name] = game.Clone()
 	return nil
 }
 
+// Update replaces the stored game when it is still at game.Version, and bumps
+// the version of both. It fails with ErrConflict otherwise.
 func (s *GameStore) Update(game *types.Game) error {
-	g := *game
-	if _, ok := s.db.games[game.Name]; !ok {
-		return errors.New("game do not exist")
+	g := game.Clone()
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.games[game.Name]
+	if !ok {
+		return ErrGameNotFound
+	}
+	if stored.Version != game.Version {
+		return ErrConflict
 	}
-	s.db.games[game.Name] = &g
+	g.Version++
+	s.db.games[game.Name] = g
+	game.Version = g.Version
 	return nil
 }
 
 func (s *GameStore) GetByName(name string) (*types.Game, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
 	if game, ok := s.db.games[name]; ok {
-		g := *game
-		return &g, nil
+		return game.Clone(), nil
+	}
+	return nil, ErrGameNotFound
+}
+
+func (s *GameStore) Delete(name string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.games[name]; !ok {
+		return ErrGameNotFound
+	}
+	delete(s.db.games, name)
+	return nil
+}
+
+// List returns the games matching query, ordered by creation time and then
+// by name.
+func (s *GameStore) List(query types.GameQuery) (*types.GamePage, error) {
+	var after *cursor
+	if query.Cursor != "" {
+		c, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		after = &c
+	}
+
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var games []*types.Game
+	for _, game := range s.db.games {
+		if matches(game, query) && (after == nil || after.before(game)) {
+			games = append(games, game)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return cursorOf(games[i]).before(games[j])
+	})
+
+	page := &types.GamePage{}
+	if query.Limit > 0 && len(games) > query.Limit {
+		games = games[:query.Limit]
+		page.Next = cursorOf(games[len(games)-1]).encode()
+	}
+	page.Games = make([]*types.Game, len(games))
+	for i, game := range games {
+		page.Games[i] = game.Clone()
+	}
+	return page, nil
+}
+
+func matches(game *types.Game, query types.GameQuery) bool {
+	switch {
+	case query.Status != "" && game.Status != query.Status:
+		return false
+	case query.Owner != "" && game.Owner != query.Owner:
+		return false
+	case !query.CreatedAfter.IsZero() && game.CreatedAt.Before(query.CreatedAfter):
+		return false
+	case !query.CreatedBefore.IsZero() && !game.CreatedAt.Before(query.CreatedBefore):
+		return false
+	case !query.UpdatedBefore.IsZero() && !game.UpdatedAt.Before(query.UpdatedBefore):
+		return false
+	case query.Rows != 0 && game.Rows != query.Rows:
+		return false
+	case query.Cols != 0 && game.Cols != query.Cols:
+		return false
 	}
-	return nil, errors.New("game not found")
+	return true
 }
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+func newGame() *types.Game {
+	return &types.Game{
+		Name:   "teste",
+		Rows:   2,
+		Cols:   2,
+		Mines:  1,
+		Status: types.StatusStarted,
+		Grid: []types.CellGrid{
+			{{Value: 1}, {Value: 1}},
+			{{Value: 1}, {Mine: true}},
+		},
+	}
+}
+
+func TestGameStore_Isolation(t *testing.T) {
+	s := NewGameStore(New())
+
+	game := newGame()
+	if err := s.Insert(game); err != nil {
+		t.Fatal(err)
+	}
+	game.Grid[0][0].Clicked = true
+
+	fetched, err := s.GetByName("teste")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Grid[0][0].Clicked {
+		t.Error("changing an inserted game should not change the stored one")
+	}
+
+	fetched.Grid[0][1].Mark = types.MarkFlag
+	fetched.Grid[1] = append(fetched.Grid[1], types.Cell{})
+	again, err := s.GetByName("teste")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Grid[0][1].Mark != types.MarkNone || len(again.Grid[1]) != 2 {
+		t.Error("changing a fetched game should not change the stored one")
+	}
+
+	if err := s.Update(fetched); err != nil {
+		t.Fatal(err)
+	}
+	fetched.Grid[1][0].Clicked = true
+	again, err = s.GetByName("teste")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Grid[0][1].Mark != types.MarkFlag {
+		t.Error("update should be stored")
+	}
+	if again.Grid[1][0].Clicked {
+		t.Error("changing an updated game should not change the stored one")
+	}
+}
+
+func TestGameStore_FailedUpdate(t *testing.T) {
+	s := NewGameStore(New())
+	if err := s.Insert(newGame()); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := s.GetByName("teste")
+	second, _ := s.GetByName("teste")
+
+	first.Grid[0][0].Clicked = true
+	if err := s.Update(first); err != nil {
+		t.Fatal(err)
+	}
+
+	second.Grid[1][1].Clicked = true
+	second.Status = types.StatusOver
+	if err := s.Update(second); err != ErrConflict {
+		t.Fatalf("unexpected error. want=%v, got=%v", ErrConflict, err)
+	}
+
+	stored, _ := s.GetByName("teste")
+	if stored.Status != types.StatusStarted || stored.Grid[1][1].Clicked || !stored.Grid[0][0].Clicked {
+		t.Errorf("a failed update should leave the stored game untouched, got=%+v", stored)
+	}
+	if stored.Version != 2 {
+		t.Errorf("unexpected version. want=2, got=%d", stored.Version)
+	}
+}
+
+func TestGameStore_List(t *testing.T) {
+	s := NewGameStore(New())
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		game := newGame()
+		game.Name = name
+		game.CreatedAt = start.Add(time.Duration(i/2) * time.Hour)
+		if i%2 == 0 {
+			game.Owner = "bob"
+			game.Status = types.StatusWon
+		}
+		if err := s.Insert(game); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query types.GameQuery
+		names string
+	}{
+		{types.GameQuery{}, "abcdef"},
+		{types.GameQuery{Owner: "bob"}, "ace"},
+		{types.GameQuery{Status: types.StatusStarted}, "bdf"},
+		{types.GameQuery{CreatedAfter: start.Add(time.Hour)}, "cdef"},
+		{types.GameQuery{CreatedBefore: start.Add(time.Hour)}, "ab"},
+		{types.GameQuery{Owner: "bob", CreatedAfter: start.Add(time.Hour), CreatedBefore: start.Add(2 * time.Hour)}, "c"},
+		{types.GameQuery{Rows: 3}, ""},
+	}
+	for _, tt := range tests {
+		page, err := s.List(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := ""
+		for _, game := range page.Games {
+			names += game.Name
+		}
+		if names != tt.names || page.Next != "" {
+			t.Errorf("%+v: unexpected games. want=%s, got=%s (next %q)", tt.query, tt.names, names, page.Next)
+		}
+	}
+
+	names := ""
+	query := types.GameQuery{Limit: 4}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("listing does not end")
+		}
+		page, err := s.List(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, game := range page.Games {
+			names += game.Name
+		}
+		if page.Next == "" {
+			break
+		}
+		query.Cursor = page.Next
+	}
+	if names != "abcdef" {
+		t.Errorf("unexpected games across pages. want=abcdef, got=%s", names)
+	}
+
+	if _, err := s.List(types.GameQuery{Cursor: "not a cursor"}); err != ErrInvalidCursor {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrInvalidCursor, err)
+	}
+}
+
+func TestGameStore_Delete(t *testing.T) {
+	s := NewGameStore(New())
+	if err := s.Insert(newGame()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Delete("teste"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetByName("teste"); err != ErrGameNotFound {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrGameNotFound, err)
+	}
+	if err := s.Delete("teste"); err != ErrGameNotFound {
+		t.Errorf("unexpected error. want=%v, got=%v", ErrGameNotFound, err)
+	}
+}
@@ -1,8 +1,14 @@
 package memory
 
-import "github.com/guilhermebr/minesweeper/types"
+import (
+	"sync"
 
+	"github.com/guilhermebr/minesweeper/types"
+)
+
+// DB keeps games in memory. It is safe for concurrent use.
 type DB struct {
+	mu    sync.RWMutex
 	games map[string]*types.Game
 }
 
@@ -1,31 +1,307 @@
 package types
 
+import "time"
+
+// Mark is the player's annotation on a cell that was not clicked yet.
+type Mark string
+
+const (
+	MarkNone     Mark = ""
+	MarkFlag     Mark = "flag"
+	MarkQuestion Mark = "question"
+)
+
 type Cell struct {
 	Mine    bool `json:"mine"`
 	Clicked bool `json:"clicked"`
 	Value   int  `json:"value"`
+	Mark    Mark `json:"mark,omitempty"`
 }
 
 type CellGrid []Cell
 
+type Position struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// Hint is a suggestion of the next cell to open. Risk is the chance of the
+// cell hiding a mine, zero when it is provably safe.
+type Hint struct {
+	Position
+	Safe   bool    `json:"safe"`
+	Risk   float64 `json:"risk"`
+	Reason string  `json:"reason"`
+}
+
+// CellProbability is the chance of an unopened cell hiding a mine.
+type CellProbability struct {
+	Position
+	Mine float64 `json:"mine"`
+}
+
+// Probabilities holds the chance of every unopened cell hiding a mine. When
+// the board is too large to be analysed exactly the chances are estimated,
+// Exact is false and Accuracy is the largest standard error of the estimates.
+type Probabilities struct {
+	Cells    []CellProbability `json:"cells"`
+	Exact    bool              `json:"exact"`
+	Accuracy float64           `json:"accuracy"`
+}
+
+// Status is the stage a game is in.
+type Status string
+
+const (
+	StatusNew         Status = "new"
+	StatusStarted     Status = "started"
+	StatusOver        Status = "over"
+	StatusWon         Status = "won"
+	StatusSurrendered Status = "surrendered"
+	StatusPaused      Status = "paused"
+	StatusTimeout     Status = "timeout"
+)
+
+// FirstClick controls where mines may be laid relative to the first click.
+type FirstClick string
+
+const (
+	// FirstClickSafe guarantees the first clicked cell is not a mine.
+	FirstClickSafe FirstClick = "safe"
+	// FirstClickOpening guarantees the first clicked cell and its neighbours
+	// are free of mines, so the first click always opens a region.
+	FirstClickOpening FirstClick = "opening"
+	// FirstClickOff lays the mines when the game starts.
+	FirstClickOff FirstClick = "off"
+)
+
+// MoveKind is the kind of action a move records.
+type MoveKind string
+
+const (
+	MoveReveal MoveKind = "reveal"
+	MoveFlag   MoveKind = "flag"
+	MoveChord  MoveKind = "chord"
+)
+
+// Move is an action of the player on a cell. Cells lists every cell the move
+// opened, or the marked cell for flags.
+type Move struct {
+	Kind MoveKind `json:"kind"`
+	Position
+	At    time.Time  `json:"at"`
+	Cells []Position `json:"cells"`
+}
+
 type Game struct {
-	Name   string     `json:"name"`
-	Rows   int        `json:"rows"`
-	Cols   int        `json:"cols"`
-	Mines  int        `json:"mines"`
-	Status string     `json:"status"`
-	Grid   []CellGrid `json:"grid,omitempty"`
-	Clicks int        `json:"-"`
+	Name           string     `json:"name"`
+	Owner          string     `json:"owner,omitempty"`
+	Parent         string     `json:"parent,omitempty"`
+	Difficulty     string     `json:"difficulty,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Rows           int        `json:"rows"`
+	Cols           int        `json:"cols"`
+	Mines          int        `json:"mines"`
+	RemainingMines int        `json:"remaining_mines"`
+	Status         Status     `json:"status"`
+	Seed           int64      `json:"seed,omitempty"`
+	FirstClick     FirstClick `json:"first_click,omitempty"`
+	NoGuess        bool       `json:"no_guess,omitempty"`
+	GuessFree      bool       `json:"guess_free,omitempty"`
+	Grid           []CellGrid `json:"grid,omitempty"`
+	Hints          int        `json:"hints,omitempty"`
+	Ranked         bool       `json:"ranked,omitempty"`
+	Moves          []Move     `json:"moves,omitempty"`
+	Undos          int        `json:"undos,omitempty"`
+	TimeLimit      int64      `json:"time_limit_ms,omitempty"`
+	Version        int64      `json:"version,omitempty"`
+	Clicks         int        `json:"-"`
+	MinesPending   bool       `json:"-"`
+
+	// The clock starts on the first reveal and stops when the game ends, or
+	// when it reaches TimeLimit, in milliseconds, if the game has one.
+	// PausedFor is the time spent paused, not counted as played, and
+	// PausedAt when the running pause began.
+	StartedAt *time.Time    `json:"started_at,omitempty"`
+	EndedAt   *time.Time    `json:"ended_at,omitempty"`
+	PausedAt  *time.Time    `json:"paused_at,omitempty"`
+	PausedFor time.Duration `json:"paused_for,omitempty"`
+
+	// Elapsed is the time played as of when the game was read. It is not
+	// stored.
+	Elapsed time.Duration `json:"-"`
+}
+
+// CellView is a cell as shown to the player. Mine and Value are only set on
+// revealed cells while the game is running.
+type CellView struct {
+	Clicked bool  `json:"clicked"`
+	Mark    Mark  `json:"mark,omitempty"`
+	Mine    *bool `json:"mine,omitempty"`
+	Value   *int  `json:"value,omitempty"`
+}
+
+// GameView is a game as shown to the player: nothing it holds gives away
+// where the mines are until the game is finished, and the board is hidden
+// while the game is paused. Elapsed is the time played in milliseconds and
+// Remaining, on games with a time limit, the time left to play.
+type GameView struct {
+	Name           string       `json:"name"`
+	Owner          string       `json:"owner,omitempty"`
+	Parent         string       `json:"parent,omitempty"`
+	Difficulty     string       `json:"difficulty,omitempty"`
+	Rows           int          `json:"rows"`
+	Cols           int          `json:"cols"`
+	Mines          int          `json:"mines"`
+	RemainingMines int          `json:"remaining_mines"`
+	Status         Status       `json:"status"`
+	StartedAt      *time.Time   `json:"started_at,omitempty"`
+	EndedAt        *time.Time   `json:"ended_at,omitempty"`
+	Elapsed        int64        `json:"elapsed_ms"`
+	TimeLimit      int64        `json:"time_limit_ms,omitempty"`
+	Remaining      *int64       `json:"remaining_ms,omitempty"`
+	Seed           int64        `json:"seed,omitempty"`
+	FirstClick     FirstClick   `json:"first_click,omitempty"`
+	NoGuess        bool         `json:"no_guess,omitempty"`
+	GuessFree      bool         `json:"guess_free,omitempty"`
+	Grid           [][]CellView `json:"grid,omitempty"`
+	Hints          int          `json:"hints,omitempty"`
+	Ranked         bool         `json:"ranked,omitempty"`
+	Undos          int          `json:"undos,omitempty"`
+	Version        int64        `json:"version,omitempty"`
+}
+
+// GameSummary describes a game without its board.
+type GameSummary struct {
+	Name       string    `json:"name"`
+	Owner      string    `json:"owner,omitempty"`
+	Parent     string    `json:"parent,omitempty"`
+	Difficulty string    `json:"difficulty,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Rows       int       `json:"rows"`
+	Cols       int       `json:"cols"`
+	Mines      int       `json:"mines"`
+	Status     Status    `json:"status"`
+	Elapsed    int64     `json:"elapsed_ms"`
+	Remaining  *int64    `json:"remaining_ms,omitempty"`
+	Version    int64     `json:"version,omitempty"`
+}
+
+// GameQuery selects the games returned by a listing. Zero fields match every
+// game; UpdatedBefore selects games idle since before that time. Games are
+// listed from the oldest to the newest; Cursor continues a previous listing
+// from where it stopped and Limit caps the games returned.
+type GameQuery struct {
+	Status        Status
+	Owner         string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedBefore time.Time
+	Rows          int
+	Cols          int
+	Cursor        string
+	Limit         int
+}
+
+// GamePage is a page of a listing. Next is the cursor of the following page,
+// empty on the last one.
+type GamePage struct {
+	Games []*Game
+	Next  string
+}
+
+// Replay is a self-contained record of a game: its settings, where its mines
+// are and every move of the player, enough to play it again move by move.
+type Replay struct {
+	Name          string        `json:"name"`
+	Owner         string        `json:"owner,omitempty"`
+	Parent        string        `json:"parent,omitempty"`
+	Difficulty    string        `json:"difficulty,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Rows          int           `json:"rows"`
+	Cols          int           `json:"cols"`
+	Mines         int           `json:"mines"`
+	Seed          int64         `json:"seed"`
+	FirstClick    FirstClick    `json:"first_click"`
+	NoGuess       bool          `json:"no_guess,omitempty"`
+	GuessFree     bool          `json:"guess_free,omitempty"`
+	Ranked        bool          `json:"ranked,omitempty"`
+	TimeLimit     int64         `json:"time_limit_ms,omitempty"`
+	MinePositions []Position    `json:"mine_positions"`
+	Moves         []Move        `json:"moves"`
+	Status        Status        `json:"status"`
+	StartedAt     *time.Time    `json:"started_at,omitempty"`
+	EndedAt       *time.Time    `json:"ended_at,omitempty"`
+	PausedFor     time.Duration `json:"paused_for,omitempty"`
+	Hints         int           `json:"hints,omitempty"`
+	Undos         int           `json:"undos,omitempty"`
+	Version       int64         `json:"version"`
+}
+
+// Clone returns a copy of g that shares no memory with it.
+func (g *Game) Clone() *Game {
+	c := *g
+	if g.Grid != nil {
+		cells := make(CellGrid, 0, g.Rows*g.Cols)
+		c.Grid = make([]CellGrid, len(g.Grid))
+		for i, row := range g.Grid {
+			start := len(cells)
+			cells = append(cells, row...)
+			c.Grid[i] = cells[start:len(cells):len(cells)]
+		}
+	}
+	if g.Moves != nil {
+		c.Moves = make([]Move, len(g.Moves))
+		for i, move := range g.Moves {
+			c.Moves[i] = move
+			c.Moves[i].Cells = append([]Position(nil), move.Cells...)
+		}
+	}
+	c.StartedAt = cloneTime(g.StartedAt)
+	c.EndedAt = cloneTime(g.EndedAt)
+	c.PausedAt = cloneTime(g.PausedAt)
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
 }
 
+// GameService plays games. Operations that change a game take the version the
+// caller last saw, and fail when the game moved on since; zero skips the check.
 type GameService interface {
 	Create(game *Game) error
-	Start(name string) (*Game, error)
-	Click(name string, i, j int) (*Game, error)
+	Start(name string, version int64) (*Game, error)
+	Click(name string, i, j int, version int64) (*Game, []Position, error)
+	Chord(name string, i, j int, version int64) (*Game, []Position, error)
+	Flag(name string, i, j int, version int64) (*Game, error)
+	Hint(name string, version int64) (*Game, *Hint, error)
+	Surrender(name string, version int64) (*Game, error)
+	Restart(name string, version int64) (*Game, error)
+	Fork(name, newName string) (*Game, error)
+	Undo(name string, moves int, version int64) (*Game, error)
+	Pause(name string, version int64) (*Game, error)
+	Resume(name string, version int64) (*Game, error)
+	Replay(name string) (*Replay, error)
+	Delete(name string, version int64) error
+	Get(name string) (*Game, error)
+	List(query GameQuery) (*GamePage, error)
+	Probabilities(name string) (*Probabilities, error)
 }
 
+// GameStore persists games. Update only succeeds when the stored game is still
+// at the version of the game passed in, and moves both to the next version.
 type GameStore interface {
 	Insert(game *Game) error
 	Update(game *Game) error
 	GetByName(name string) (*Game, error)
+	List(query GameQuery) (*GamePage, error)
+	Delete(name string) error
 }